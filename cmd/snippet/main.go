@@ -0,0 +1,13 @@
+// The snippet command creates, reads, lists and deletes snippets on a
+// snippetbox server through its JSON API. See the cli package for its usage.
+package main
+
+import (
+	"os"
+
+	"github.com/Overlrd/snippetbox/internal/cli"
+)
+
+func main() {
+	os.Exit(cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, os.Getenv))
+}
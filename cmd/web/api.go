@@ -0,0 +1,407 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Overlrd/snippetbox/internal/models"
+	"github.com/Overlrd/snippetbox/internal/syntax"
+)
+
+// Define an apiSnippet type to hold the JSON representation of a snippet.
+// Listings leave out the fields which they don't load, such as the files.
+// Expires is null for snippets which never expire.
+type apiSnippet struct {
+	ID               int        `json:"id"`
+	Title            string     `json:"title"`
+	Author           string     `json:"author,omitempty"`
+	Visibility       string     `json:"visibility"`
+	Slug             string     `json:"slug,omitempty"`
+	URL              string     `json:"url"`
+	ParentID         int        `json:"parent_id,omitempty"`
+	Revision         int        `json:"revision,omitempty"`
+	BurnAfterReading bool       `json:"burn_after_reading,omitempty"`
+	Stars            int        `json:"stars"`
+	Files            []apiFile  `json:"files,omitempty"`
+	Tags             []string   `json:"tags,omitempty"`
+	Created          time.Time  `json:"created"`
+	Expires          *time.Time `json:"expires"`
+}
+
+// Define an apiFile type to hold the JSON representation of a file of a
+// snippet, which is also used when creating and updating snippets.
+type apiFile struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// The newAPISnippet() helper converts a snippet into its JSON representation.
+// The slug is only included for snippets which aren't public, as public
+// snippets are reached through their ID.
+func newAPISnippet(s models.Snippet) apiSnippet {
+	snippet := apiSnippet{
+		ID:               s.ID,
+		Title:            s.Title,
+		Author:           s.UserName,
+		Visibility:       s.Visibility,
+		URL:              snippetURL(s),
+		ParentID:         s.ParentID,
+		Revision:         s.Revision,
+		BurnAfterReading: s.BurnAfterReading,
+		Stars:            s.Stars,
+		Tags:             s.Tags,
+		Created:          s.Created,
+	}
+
+	if s.Visibility != models.VisibilityPublic {
+		snippet.Slug = s.Slug
+	}
+
+	if !s.Expires.IsZero() {
+		snippet.Expires = &s.Expires
+	}
+
+	for _, f := range s.Files {
+		snippet.Files = append(snippet.Files, apiFile{Name: f.Name, Language: f.Language, Content: f.Content})
+	}
+
+	return snippet
+}
+
+// Define an apiSnippetInput type to hold the JSON body of the requests which
+// create and update snippets. Its fields mirror those of snippetCreateForm,
+// except that tags are a list and expires_at is an RFC 3339 time, which
+// implies a custom expiry. Fields which are left out keep their current
+// value when updating a snippet, and their default value when creating one;
+// files without a language are plain text.
+type apiSnippetInput struct {
+	Title      *string    `json:"title"`
+	Files      []apiFile  `json:"files"`
+	Visibility *string    `json:"visibility"`
+	Tags       []string   `json:"tags"`
+	Expires    *string    `json:"expires"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// The apply() method copies the fields which were given in the input onto a
+// snippet form, so that it can be validated like the web form.
+func (input apiSnippetInput) apply(form *snippetCreateForm) {
+	if input.Title != nil {
+		form.Title = *input.Title
+	}
+
+	if input.Files != nil {
+		form.Files = nil
+		for _, f := range input.Files {
+			if f.Language == "" {
+				f.Language = syntax.PlainText
+			}
+			form.Files = append(form.Files, snippetFileForm{Name: f.Name, Language: f.Language, Content: f.Content})
+		}
+	}
+
+	if input.Visibility != nil {
+		form.Visibility = *input.Visibility
+	}
+
+	if input.Tags != nil {
+		form.Tags = strings.Join(input.Tags, " ")
+	}
+
+	if input.Expires != nil {
+		form.Expires = *input.Expires
+		form.ExpiresAt = ""
+	}
+
+	if input.ExpiresAt != nil {
+		if input.Expires == nil {
+			form.Expires = customExpiry
+		}
+		form.ExpiresAt = input.ExpiresAt.UTC().Format(expiresAtLayout)
+	}
+}
+
+// apiSnippetList: List the public snippets, optionally only those matching
+// the "q" search query or tagged with the "tag" query string parameter
+func (app *application) apiSnippetList(w http.ResponseWriter, r *http.Request) {
+	page, ok := readPage(r)
+	if !ok {
+		app.errorJSON(w, r, http.StatusBadRequest, "page must be a positive integer")
+		return
+	}
+
+	var (
+		snippets []models.Snippet
+		total    int
+		err      error
+	)
+
+	query := r.URL.Query()
+
+	switch {
+	case strings.TrimSpace(query.Get("q")) != "":
+		snippets, total, err = app.snippets.Search(strings.TrimSpace(query.Get("q")), page, snippetsPerPage)
+	case query.Get("tag") != "":
+		snippets, total, err = app.snippets.ListByTag(query.Get("tag"), page, snippetsPerPage)
+	default:
+		snippets, total, err = app.snippets.List(page, snippetsPerPage)
+	}
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	// Always send a list, even when it is empty.
+	list := []apiSnippet{}
+	for _, s := range snippets {
+		list = append(list, newAPISnippet(s))
+	}
+
+	p := newPagination(r, page, snippetsPerPage, total)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{
+		"snippets": list,
+		"metadata": envelope{
+			"page":          p.Page,
+			"page_size":     p.PageSize,
+			"total_records": p.TotalRecords,
+			"last_page":     p.LastPage(),
+		},
+	})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+// apiSnippetGet: Send a snippet, identified by its ID or slug, along with its
+// files and tags
+func (app *application) apiSnippetGet(w http.ResponseWriter, r *http.Request) {
+	snippet, err := app.viewableSnippet(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.clientErrorJSON(w, r, http.StatusNotFound)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	// Burn-after-reading snippets can only be revealed through the warning
+	// page, like for the raw endpoint.
+	if snippet.BurnAfterReading && !app.isSnippetOwner(r, snippet) {
+		app.clientErrorJSON(w, r, http.StatusForbidden)
+		return
+	}
+
+	snippet.Tags, err = app.tags.ForSnippet(snippet.ID)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"snippet": newAPISnippet(snippet)})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+// apiSnippetCreate: Create a snippet owned by the current user. Snippets are
+// public and expire in a year unless the request says otherwise.
+func (app *application) apiSnippetCreate(w http.ResponseWriter, r *http.Request) {
+	var input apiSnippetInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	form := snippetCreateForm{
+		Visibility: models.VisibilityPublic,
+		Expires:    "365",
+	}
+	input.apply(&form)
+
+	form.validate(app.maxExpiry)
+
+	if !form.Valid() {
+		app.failedValidationJSON(w, r, form.Validator)
+		return
+	}
+
+	expires, burn := form.expiry()
+
+	id, err := app.snippets.Insert(app.authenticatedUserID(r), form.Title, form.files(), form.Visibility, expires, burn)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	err = app.tags.Set(id, form.tagNames())
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	// Fetch the new snippet to send it back along with its slug.
+	snippet, err := app.snippets.Get(id)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+	snippet.Tags = form.tagNames()
+
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/snippets/%d", id))
+
+	err = app.writeJSON(w, http.StatusCreated, envelope{"snippet": newAPISnippet(snippet)})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+// apiSnippetUpdate: Change the fields given in the request of a snippet owned
+// by the current user
+func (app *application) apiSnippetUpdate(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.apiOwnSnippet(w, r, false)
+	if !ok {
+		return
+	}
+
+	var input apiSnippetInput
+
+	err := app.readJSON(w, r, &input)
+	if err != nil {
+		app.errorJSON(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tags, err := app.tags.ForSnippet(snippet.ID)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	form := newSnippetEditForm(snippet, tags)
+
+	// Leave the expiry alone unless the request changes it, as checking the
+	// current one again would fail for snippets which are about to expire.
+	keepExpiry := input.Expires == nil && input.ExpiresAt == nil
+	if keepExpiry {
+		form.Expires = neverExpires
+		form.ExpiresAt = ""
+	}
+
+	input.apply(&form)
+
+	form.validate(app.maxExpiry)
+
+	if !form.Valid() {
+		app.failedValidationJSON(w, r, form.Validator)
+		return
+	}
+
+	expires, burn := form.expiry()
+	if keepExpiry {
+		expires, burn = snippet.Expires, snippet.BurnAfterReading
+	}
+
+	err = app.snippets.Update(snippet.ID, app.authenticatedUserID(r), form.Title, form.files(), form.Visibility, expires, burn)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.clientErrorJSON(w, r, http.StatusNotFound)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	err = app.tags.Set(snippet.ID, form.tagNames())
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+
+	snippet, err = app.snippets.Get(snippet.ID)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+		return
+	}
+	snippet.Tags = form.tagNames()
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"snippet": newAPISnippet(snippet)})
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+// apiSnippetDelete: Delete a snippet owned by the current user, or by anyone
+// if the current user is an administrator
+func (app *application) apiSnippetDelete(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.apiOwnSnippet(w, r, true)
+	if !ok {
+		return
+	}
+
+	err := app.snippets.Delete(snippet.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.clientErrorJSON(w, r, http.StatusNotFound)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// The apiOwnSnippet() helper fetches the snippet identified by the numeric
+// "id" wildcard for the API and checks that it belongs to the current user,
+// like the requireSnippetOwner middleware, optionally letting administrators
+// through. If it doesn't, it sends the error response and returns false.
+func (app *application) apiOwnSnippet(w http.ResponseWriter, r *http.Request, allowAdmin bool) (models.Snippet, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		app.clientErrorJSON(w, r, http.StatusNotFound)
+		return models.Snippet{}, false
+	}
+
+	snippet, err := app.snippets.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			app.clientErrorJSON(w, r, http.StatusNotFound)
+		} else {
+			app.serverErrorJSON(w, r, err)
+		}
+		return models.Snippet{}, false
+	}
+
+	userID := app.authenticatedUserID(r)
+
+	// Like on the website, the snippets of other users are not found, so
+	// that private and unlisted snippets can't be discovered by their ID.
+	if snippet.UserID != userID {
+		if !allowAdmin {
+			app.clientErrorJSON(w, r, http.StatusNotFound)
+			return models.Snippet{}, false
+		}
+
+		user, err := app.users.Get(userID)
+		if err != nil {
+			app.serverErrorJSON(w, r, err)
+			return models.Snippet{}, false
+		}
+
+		if !user.IsAdmin {
+			app.clientErrorJSON(w, r, http.StatusNotFound)
+			return models.Snippet{}, false
+		}
+	}
+
+	return snippet, true
+}
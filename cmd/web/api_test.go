@@ -0,0 +1,448 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+	"github.com/Overlrd/snippetbox/internal/models"
+	"github.com/Overlrd/snippetbox/internal/models/mocks"
+)
+
+// The createdSnippetModel type behaves like the mock snippet model, except
+// that the snippet created by Insert() can be fetched afterwards.
+type createdSnippetModel struct {
+	mocks.SnippetModel
+}
+
+func (m *createdSnippetModel) Get(id int) (models.Snippet, error) {
+	if id == 2 {
+		return models.Snippet{
+			ID:         2,
+			UserID:     1,
+			UserName:   "Alice",
+			Title:      "Created from the API",
+			Files:      []models.File{{Name: "hello.txt", Language: "text", Content: "Hello!"}},
+			Visibility: models.VisibilityUnlisted,
+			Slug:       "Y3JlYXRlZC1zbmlwcGV0LTI",
+			Revision:   1,
+		}, nil
+	}
+
+	return m.SnippetModel.Get(id)
+}
+
+// The bearerAuth() helper returns the headers of an API request with a JSON
+// body, authenticated with the given API token if it isn't empty.
+func bearerAuth(token string) http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+
+	return header
+}
+
+func TestAPISnippetList(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "First page",
+			urlPath:  "/api/v1/snippets",
+			wantCode: http.StatusOK,
+			wantBody: `"title": "An old silent pond"`,
+		},
+		{
+			name:     "Pagination",
+			urlPath:  "/api/v1/snippets",
+			wantCode: http.StatusOK,
+			wantBody: `"total_records": 1`,
+		},
+		{
+			name:     "Out of range page",
+			urlPath:  "/api/v1/snippets?page=2",
+			wantCode: http.StatusOK,
+			wantBody: `"snippets": []`,
+		},
+		{
+			name:     "Invalid page",
+			urlPath:  "/api/v1/snippets?page=first",
+			wantCode: http.StatusBadRequest,
+			wantBody: `"error": "page must be a positive integer"`,
+		},
+		{
+			name:     "Search",
+			urlPath:  "/api/v1/snippets?q=silent",
+			wantCode: http.StatusOK,
+			wantBody: `"title": "An old silent pond"`,
+		},
+		{
+			name:     "Search without results",
+			urlPath:  "/api/v1/snippets?q=lighthouse",
+			wantCode: http.StatusOK,
+			wantBody: `"snippets": []`,
+		},
+		{
+			name:     "Tag",
+			urlPath:  "/api/v1/snippets?tag=haiku",
+			wantCode: http.StatusOK,
+			wantBody: `"title": "An old silent pond"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, headers, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Content-Type"), "application/json")
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetGet(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		token    string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Valid ID",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusOK,
+			wantBody: `"content": "An old silent pond..."`,
+		},
+		{
+			name:     "Tags",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusOK,
+			wantBody: `"haiku"`,
+		},
+		{
+			name:     "Unlisted by slug",
+			urlPath:  "/api/v1/snippets/dW5saXN0ZWQtc25pcHBldDM",
+			wantCode: http.StatusOK,
+			wantBody: `"slug": "dW5saXN0ZWQtc25pcHBldDM"`,
+		},
+		{
+			name:     "Unlisted by ID",
+			urlPath:  "/api/v1/snippets/3",
+			wantCode: http.StatusNotFound,
+			wantBody: `"error": "not found"`,
+		},
+		{
+			name:     "Private",
+			urlPath:  "/api/v1/snippets/4",
+			wantCode: http.StatusNotFound,
+			wantBody: `"error": "not found"`,
+		},
+		{
+			name:     "Private as owner",
+			urlPath:  "/api/v1/snippets/4",
+			token:    "sbx_alice",
+			wantCode: http.StatusOK,
+			wantBody: `"title": "First autumn morning"`,
+		},
+		{
+			name:     "Burn after reading",
+			urlPath:  "/api/v1/snippets/YnVybi1zbmlwcGV0LWZpdmU",
+			wantCode: http.StatusForbidden,
+			wantBody: `"error": "forbidden"`,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/api/v1/snippets/2",
+			wantCode: http.StatusNotFound,
+			wantBody: `"error": "not found"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.do(t, http.MethodGet, tt.urlPath, nil, bearerAuth(tt.token))
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetCreate(t *testing.T) {
+	app := newTestApplication(t)
+	app.snippets = &createdSnippetModel{}
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	const validBody = `{"title": "Created from the API", "files": [{"name": "hello.txt", "language": "text", "content": "Hello!"}], "visibility": "unlisted", "tags": ["greeting"]}`
+
+	tests := []struct {
+		name         string
+		token        string
+		contentType  string
+		body         string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Valid submission",
+			token:        "sbx_alice",
+			body:         validBody,
+			wantCode:     http.StatusCreated,
+			wantLocation: "/api/v1/snippets/2",
+			wantBody:     `"slug": "Y3JlYXRlZC1zbmlwcGV0LTI"`,
+		},
+		{
+			name:         "Defaults",
+			token:        "sbx_alice",
+			body:         `{"title": "Hello", "files": [{"content": "Hello!"}]}`,
+			wantCode:     http.StatusCreated,
+			wantLocation: "/api/v1/snippets/2",
+			wantBody:     `"url": "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI"`,
+		},
+		{
+			name:     "Anonymous",
+			body:     validBody,
+			wantCode: http.StatusUnauthorized,
+			wantBody: `"error": "you must be authenticated to access this resource"`,
+		},
+		{
+			name:     "Unknown token",
+			token:    "sbx_mallory",
+			body:     validBody,
+			wantCode: http.StatusUnauthorized,
+			wantBody: `"error": "invalid or expired authentication token"`,
+		},
+		{
+			name:     "Blank title",
+			token:    "sbx_alice",
+			body:     `{"title": "", "files": [{"content": "Hello!"}]}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"title": "This field cannot be blank"`,
+		},
+		{
+			name:     "Invalid file",
+			token:    "sbx_alice",
+			body:     `{"title": "Hello", "files": [{"name": "hello.txt", "language": "klingon", "content": "Hello!"}]}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"files[0].language": "This field must be one of the listed languages"`,
+		},
+		{
+			name:     "Invalid expires",
+			token:    "sbx_alice",
+			body:     `{"title": "Hello", "files": [{"content": "Hello!"}], "expires": "tomorrow"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"expires": "This field must equal 1, 7, 365, never, custom or burn"`,
+		},
+		{
+			name:     "Custom expiry in the past",
+			token:    "sbx_alice",
+			body:     `{"title": "Hello", "files": [{"content": "Hello!"}], "expires": "custom", "expires_at": "2001-01-01T00:00:00Z"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"expires_at": "This field must be in the future"`,
+		},
+		{
+			name:     "Badly-formed JSON",
+			token:    "sbx_alice",
+			body:     `{"title": "Hello",`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `"error": "body contains badly-formed JSON"`,
+		},
+		{
+			name:     "Unknown field",
+			token:    "sbx_alice",
+			body:     `{"name": "Hello"}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `"error": "body contains unknown field \"name\""`,
+		},
+		{
+			name:     "Wrong type",
+			token:    "sbx_alice",
+			body:     `{"title": 42}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `"error": "body contains incorrect JSON type for field \"title\""`,
+		},
+		{
+			name:     "Empty body",
+			token:    "sbx_alice",
+			wantCode: http.StatusBadRequest,
+			wantBody: `"error": "body must not be empty"`,
+		},
+		{
+			name:         "JSON with a charset",
+			token:        "sbx_alice",
+			contentType:  "application/json; charset=utf-8",
+			body:         validBody,
+			wantCode:     http.StatusCreated,
+			wantLocation: "/api/v1/snippets/2",
+		},
+		{
+			name:        "Not JSON",
+			token:       "sbx_alice",
+			contentType: "text/plain",
+			body:        validBody,
+			wantCode:    http.StatusBadRequest,
+			wantBody:    `"error": "body must have the Content-Type application/json"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := bearerAuth(tt.token)
+			if tt.contentType != "" {
+				header.Set("Content-Type", tt.contentType)
+			}
+
+			// No Origin header or CSRF token is sent, as the API doesn't
+			// use the noSurf middleware.
+			code, headers, body := ts.do(t, http.MethodPost, "/api/v1/snippets", strings.NewReader(tt.body), header)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetUpdate(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		token    string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Owner",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "sbx_alice",
+			body:     `{"title": "An old silent pond"}`,
+			wantCode: http.StatusOK,
+			wantBody: `"title": "An old silent pond"`,
+		},
+		{
+			name:     "Other user",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "sbx_bob",
+			body:     `{"title": "Mine now"}`,
+			wantCode: http.StatusNotFound,
+			wantBody: `"error": "not found"`,
+		},
+		{
+			name:     "Anonymous",
+			urlPath:  "/api/v1/snippets/1",
+			body:     `{"title": "Mine now"}`,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Invalid visibility",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "sbx_alice",
+			body:     `{"visibility": "secret"}`,
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: `"visibility": "This field must equal public, unlisted or private"`,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/api/v1/snippets/2",
+			token:    "sbx_alice",
+			body:     `{"title": "Hello"}`,
+			wantCode: http.StatusNotFound,
+			wantBody: `"error": "not found"`,
+		},
+		{
+			name:     "Slug",
+			urlPath:  "/api/v1/snippets/dW5saXN0ZWQtc25pcHBldDM",
+			token:    "sbx_alice",
+			body:     `{"title": "Hello"}`,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.do(t, http.MethodPatch, tt.urlPath, strings.NewReader(tt.body), bearerAuth(tt.token))
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestAPISnippetDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		token    string
+		wantCode int
+	}{
+		{
+			name:     "Owner",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "sbx_alice",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Other user",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "sbx_bob",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Administrator",
+			urlPath:  "/api/v1/snippets/1",
+			token:    "sbx_carol",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Anonymous",
+			urlPath:  "/api/v1/snippets/1",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/api/v1/snippets/2",
+			token:    "sbx_alice",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, _ := ts.do(t, http.MethodDelete, tt.urlPath, nil, bearerAuth(tt.token))
+
+			assert.Equal(t, code, tt.wantCode)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+	"github.com/Overlrd/snippetbox/internal/cli"
+)
+
+// The tests in this file run the snippet command against the real handlers.
+
+// The runCLI helper runs the snippet command against the test server,
+// authenticated with the given API token, and returns the exit status and
+// what was written to stdout and stderr. The config file trusts the
+// certificate of the test server.
+func (ts *testServer) runCLI(t *testing.T, token, stdin string, args ...string) (int, string, string) {
+	dir := t.TempDir()
+
+	caFile := filepath.Join(dir, "cert.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config, err := json.Marshal(map[string]string{"server": ts.URL, "token": token, "ca_file": caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	configFile := filepath.Join(dir, "config.json")
+	err = os.WriteFile(configFile, config, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	args = append([]string{"-config", configFile}, args...)
+	code := cli.Run(args, strings.NewReader(stdin), &stdout, &stderr, func(string) string { return "" })
+
+	return code, stdout.String(), stderr.String()
+}
+
+func TestCLIPush(t *testing.T) {
+	app := newTestApplication(t)
+	snippets := &pastedSnippetModel{}
+	app.snippets = snippets
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	err := os.WriteFile(path, []byte("package main\n"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("File with flags after it", func(t *testing.T) {
+		code, stdout, stderr := ts.runCLI(t, "sbx_alice", "", "push", path, "--expires", "burn", "-tags", "go,cli")
+
+		assert.Equal(t, code, 0)
+		assert.Equal(t, stderr, "")
+		assert.Equal(t, stdout, ts.URL+"/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI\n")
+		assert.Equal(t, snippets.userID, 1)
+		assert.Equal(t, snippets.title, "main.go")
+		assert.Equal(t, snippets.burn, true)
+		assert.Equal(t, len(snippets.files), 1)
+		assert.Equal(t, snippets.files[0].Name, "main.go")
+		assert.Equal(t, snippets.files[0].Language, "go")
+		assert.Equal(t, snippets.files[0].Content, "package main\n")
+	})
+
+	t.Run("Stdin", func(t *testing.T) {
+		code, _, _ := ts.runCLI(t, "sbx_bob", "echo hello\n", "push", "-title", "Greeting", "-name", "hello.sh", "-visibility", "unlisted")
+
+		assert.Equal(t, code, 0)
+		assert.Equal(t, snippets.userID, 2)
+		assert.Equal(t, snippets.title, "Greeting")
+		assert.Equal(t, snippets.visibility, "unlisted")
+		assert.Equal(t, snippets.burn, false)
+		assert.Equal(t, snippets.files[0].Name, "hello.sh")
+		assert.Equal(t, snippets.files[0].Language, "bash")
+		assert.Equal(t, snippets.files[0].Content, "echo hello\n")
+	})
+
+	t.Run("Invalid expiry", func(t *testing.T) {
+		code, _, stderr := ts.runCLI(t, "sbx_alice", "x", "push", "-expires", "3")
+
+		assert.Equal(t, code, 1)
+		assert.StringContains(t, stderr, "snippet push: the request contains invalid data\n  expires: This field must equal 1, 7, 365, never, custom or burn")
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, _, stderr := ts.runCLI(t, "", "x", "push")
+
+		assert.Equal(t, code, 1)
+		assert.Equal(t, stderr, "snippet push: you must be authenticated to access this resource\n")
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		code, _, stderr := ts.runCLI(t, "sbx_alice", "", "push", "nope.go")
+
+		assert.Equal(t, code, 1)
+		assert.StringContains(t, stderr, "snippet push: open nope.go")
+	})
+}
+
+func TestCLIGet(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "All files",
+			args:       []string{"get", "1"},
+			wantStdout: "==> pond.txt <==\nAn old silent pond...\n\n==> frog.txt <==\nA frog jumps into the pond,\nsplash! Silence again.\n",
+		},
+		{
+			name:       "One file",
+			args:       []string{"get", "1", "-file", "pond.txt"},
+			wantStdout: "An old silent pond...\n",
+		},
+		{
+			name:       "Unlisted snippet by slug",
+			args:       []string{"get", "dW5saXN0ZWQtc25pcHBldDM"},
+			wantStdout: "Over the wintry forest, winds howl in rage...\n",
+		},
+		{
+			name:       "Unknown file",
+			args:       []string{"get", "-file", "toad.txt", "1"},
+			wantCode:   1,
+			wantStderr: "snippet get: snippet 1 has no file named \"toad.txt\"\n",
+		},
+		{
+			name:       "Non-existent snippet",
+			args:       []string{"get", "2"},
+			wantCode:   1,
+			wantStderr: "snippet get: not found\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := ts.runCLI(t, "", "", tt.args...)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, stdout, tt.wantStdout)
+			assert.Equal(t, stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestCLILs(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, stdout, _ := ts.runCLI(t, "", "", "ls")
+	assert.Equal(t, code, 0)
+	assert.StringContains(t, stdout, "ID  TITLE               AUTHOR  CREATED")
+	assert.StringContains(t, stdout, "1   An old silent pond  Alice")
+
+	code, stdout, _ = ts.runCLI(t, "", "", "ls", "-tag", "missing")
+	assert.Equal(t, code, 0)
+	assert.Equal(t, stdout, "ID  TITLE  AUTHOR  CREATED  EXPIRES\n")
+}
+
+func TestCLIRm(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	code, _, stderr := ts.runCLI(t, "sbx_bob", "", "rm", "1")
+	assert.Equal(t, code, 1)
+	assert.Equal(t, stderr, "snippet rm: not found\n")
+
+	code, stdout, _ := ts.runCLI(t, "sbx_alice", "", "rm", "1")
+	assert.Equal(t, code, 0)
+	assert.Equal(t, stdout, "Deleted snippet 1\n")
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+	"github.com/Overlrd/snippetbox/internal/client"
+)
+
+// The tests in this file run the API client used by the snippet command
+// against the real handlers, to check that both sides agree on the API.
+
+func newTestClient(ts *testServer, token string) *client.Client {
+	return &client.Client{BaseURL: ts.URL, Token: token, HTTPClient: ts.Client()}
+}
+
+func TestClientList(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	p, err := newTestClient(ts, "").List(1, "haiku", "")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(p.Snippets), 1)
+	assert.Equal(t, p.Snippets[0].ID, 1)
+	assert.Equal(t, p.Snippets[0].Title, "An old silent pond")
+	assert.Equal(t, p.Snippets[0].URL, "/snippet/view/1")
+	assert.Equal(t, p.Metadata.TotalRecords, 1)
+	assert.Equal(t, p.Metadata.LastPage, 1)
+
+	p, err = newTestClient(ts, "").List(1, "", "no such words")
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(p.Snippets), 0)
+}
+
+func TestClientGet(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name       string
+		token      string
+		id         string
+		wantTitle  string
+		wantFiles  int
+		wantStatus int
+	}{
+		{
+			name:      "Public snippet",
+			id:        "1",
+			wantTitle: "An old silent pond",
+			wantFiles: 2,
+		},
+		{
+			name:      "Unlisted snippet by slug",
+			id:        "dW5saXN0ZWQtc25pcHBldDM",
+			wantFiles: 1,
+		},
+		{
+			name:       "Non-existent snippet",
+			id:         "2",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "Burn after reading",
+			token:      "sbx_bob",
+			id:         "YnVybi1zbmlwcGV0LWZpdmU",
+			wantStatus: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippet, err := newTestClient(ts, tt.token).Get(tt.id)
+
+			if tt.wantStatus != 0 {
+				apiErr, ok := err.(*client.Error)
+				assert.Equal(t, ok, true)
+				if ok {
+					assert.Equal(t, apiErr.StatusCode, tt.wantStatus)
+				}
+				assert.Equal(t, client.IsNotFound(err), tt.wantStatus == http.StatusNotFound)
+				return
+			}
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, len(snippet.Files), tt.wantFiles)
+			if tt.wantTitle != "" {
+				assert.Equal(t, snippet.Title, tt.wantTitle)
+			}
+		})
+	}
+}
+
+func TestClientCreate(t *testing.T) {
+	app := newTestApplication(t)
+	app.snippets = &createdSnippetModel{}
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	snippet := client.NewSnippet{
+		Title:      "Created from the API",
+		Files:      []client.File{{Name: "main.go", Language: "go", Content: "package main"}},
+		Visibility: "unlisted",
+		Tags:       []string{"go"},
+		Expires:    "7",
+	}
+
+	t.Run("Valid", func(t *testing.T) {
+		created, err := newTestClient(ts, "sbx_alice").Create(snippet)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, created.ID, 2)
+		assert.Equal(t, created.URL, "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI")
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		_, err := newTestClient(ts, "").Create(snippet)
+		assert.StringContains(t, err.Error(), "you must be authenticated to access this resource")
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		_, err := newTestClient(ts, "sbx_nope").Create(snippet)
+		assert.StringContains(t, err.Error(), "invalid or expired authentication token")
+	})
+
+	t.Run("Invalid data", func(t *testing.T) {
+		invalid := snippet
+		invalid.Expires = "3"
+
+		_, err := newTestClient(ts, "sbx_alice").Create(invalid)
+
+		apiErr, ok := err.(*client.Error)
+		assert.Equal(t, ok, true)
+		if ok {
+			assert.Equal(t, apiErr.StatusCode, http.StatusUnprocessableEntity)
+			assert.Equal(t, apiErr.Fields["expires"], "This field must equal 1, 7, 365, never, custom or burn")
+		}
+		assert.StringContains(t, err.Error(), "expires: This field must equal 1, 7, 365, never, custom or burn")
+	})
+}
+
+func TestClientDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	err := newTestClient(ts, "sbx_bob").Delete(1)
+	assert.Equal(t, client.IsNotFound(err), true)
+
+	err = newTestClient(ts, "sbx_alice").Delete(2)
+	assert.Equal(t, client.IsNotFound(err), true)
+
+	err = newTestClient(ts, "sbx_alice").Delete(1)
+	assert.Equal(t, err, nil)
+}
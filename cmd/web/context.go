@@ -3,3 +3,11 @@ package main
 type contextKey string
 
 const IsAuthenticatedContextKey = contextKey("IsAuthenticated")
+
+// The AuthenticatedUserIDContextKey holds the ID of the user authenticated by
+// the API middleware, as API requests don't have a session.
+const AuthenticatedUserIDContextKey = contextKey("authenticatedUserID")
+
+// The SnippetContextKey holds the snippet loaded by the snippet authorization
+// middleware, so that handlers don't need to fetch it a second time.
+const SnippetContextKey = contextKey("snippet")
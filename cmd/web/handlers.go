@@ -1,12 +1,21 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
 	"errors"
 	"fmt"
+	"mime"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
 
+	"github.com/Overlrd/snippetbox/internal/diff"
 	"github.com/Overlrd/snippetbox/internal/models"
+	"github.com/Overlrd/snippetbox/internal/syntax"
 	"github.com/Overlrd/snippetbox/internal/validator"
 )
 
@@ -15,70 +24,1249 @@ import (
 // The struct tag 'form:"-"' telles the decoder to completely ignore a
 // field during decoding
 type snippetCreateForm struct {
-	Title               string `form:"title"`
+	Title               string            `form:"title"`
+	Files               []snippetFileForm `form:"files"`
+	Visibility          string            `form:"visibility"`
+	Tags                string            `form:"tags"`
+	Expires             string            `form:"expires"`
+	ExpiresAt           string            `form:"expires_at"`
+	validator.Validator `form:"-"`
+}
+
+// Define a snippetFileForm struct to hold one of the file blocks of the
+// snippet form. The fields are named like "files[0].content".
+type snippetFileForm struct {
+	Name     string `form:"name"`
+	Language string `form:"language"`
+	Content  string `form:"content"`
+}
+
+// The maximum number of files in a snippet.
+const maxFiles = 10
+
+// The normalizeFiles() method drops the file blocks which were left empty,
+// keeping at least one so that its errors can be shown, and names unnamed
+// files after their position and language, such as "file2.go".
+func (form *snippetCreateForm) normalizeFiles() {
+	var files []snippetFileForm
+	for _, f := range form.Files {
+		if strings.TrimSpace(f.Name) != "" || strings.TrimSpace(f.Content) != "" {
+			files = append(files, f)
+		}
+	}
+
+	if len(files) == 0 {
+		files = []snippetFileForm{{Language: syntax.PlainText}}
+	}
+
+	for i := range files {
+		files[i].Name = strings.TrimSpace(files[i].Name)
+		if files[i].Name == "" {
+			files[i].Name = fmt.Sprintf("file%d%s", i+1, syntax.Extension(files[i].Language))
+		}
+	}
+
+	form.Files = files
+}
+
+// The files() method converts the file blocks of the form into the files of
+// a snippet.
+func (form *snippetCreateForm) files() []models.File {
+	files := make([]models.File, len(form.Files))
+	for i, f := range form.Files {
+		files[i] = models.File{Name: f.Name, Language: f.Language, Content: f.Content}
+	}
+
+	return files
+}
+
+// The value of the expires field for burn-after-reading snippets, and the
+// number of days after which they expire if nobody reads them.
+const (
+	burnAfterReading     = "burn"
+	burnAfterReadingDays = 7
+)
+
+// The values of the expires field for snippets which never expire, and for
+// snippets which expire at the date and time of the expires_at field. That
+// field is filled in by a datetime-local input, in UTC.
+const (
+	neverExpires    = "never"
+	customExpiry    = "custom"
+	expiresAtLayout = "2006-01-02T15:04"
+)
+
+// The expiry() method converts the expires fields into the time at which
+// the snippet expires, which is zero if it never does, and whether the
+// snippet should be burnt after reading. It must only be called on a
+// validated form.
+func (form *snippetCreateForm) expiry() (time.Time, bool) {
+	now := time.Now().UTC()
+
+	switch form.Expires {
+	case burnAfterReading:
+		return now.AddDate(0, 0, burnAfterReadingDays), true
+	case neverExpires:
+		return time.Time{}, false
+	case customExpiry:
+		expires, _ := time.Parse(expiresAtLayout, form.ExpiresAt)
+		return expires, false
+	default:
+		days, _ := strconv.Atoi(form.Expires)
+		return now.AddDate(0, 0, days), false
+	}
+}
+
+// The maximum number of tags which can be attached to a snippet.
+const maxTags = 5
+
+// The tagNames() method splits the free-form tags field on commas and
+// whitespace, returning the distinct tag names in lowercase.
+func (form *snippetCreateForm) tagNames() []string {
+	fields := strings.FieldsFunc(strings.ToLower(form.Tags), func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+
+	var names []string
+	for _, field := range fields {
+		if !slices.Contains(names, field) {
+			names = append(names, field)
+		}
+	}
+
+	return names
+}
+
+// The validate() method runs the validation checks which apply to both new
+// and edited snippets, using the embedded Validator struct's CheckField()
+// method. The errors of each file are keyed like "files[0].content". Custom
+// expiry dates can be at most maxExpiry from now.
+func (form *snippetCreateForm) validate(maxExpiry time.Duration) {
+	form.normalizeFiles()
+
+	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
+	form.CheckField(validator.MaxItems(form.Files, maxFiles), "files", fmt.Sprintf("A snippet cannot contain more than %d files", maxFiles))
+
+	for i, f := range form.Files {
+		key := fmt.Sprintf("files[%d].", i)
+
+		form.CheckField(validator.MaxChars(f.Name, 100), key+"name", "This field cannot be more than 100 characters long")
+		form.CheckField(validator.Matches(f.Name, validator.FileNameRX) && f.Name != "." && f.Name != "..", key+"name", "File names can contain only letters, digits and . _ -")
+		form.CheckField(!slices.ContainsFunc(form.Files[:i], func(other snippetFileForm) bool {
+			return other.Name == f.Name
+		}), key+"name", "Each file must have a different name")
+		form.CheckField(validator.NotBlank(f.Content), key+"content", "This field cannot be blank")
+		form.CheckField(validator.PermittedValue(f.Language, syntax.Names()...), key+"language", "This field must be one of the listed languages")
+	}
+
+	form.CheckField(validator.PermittedValue(form.Visibility, models.VisibilityPublic, models.VisibilityUnlisted, models.VisibilityPrivate), "visibility", "This field must equal public, unlisted or private")
+	form.CheckField(validator.MaxItems(form.tagNames(), maxTags), "tags", fmt.Sprintf("This field cannot contain more than %d tags", maxTags))
+	form.CheckField(validator.AllMatch(form.tagNames(), validator.TagRX), "tags", "Tags must be at most 30 characters long and contain only letters, digits and + # . _ -")
+	form.CheckField(validator.PermittedValue(form.Expires, "1", "7", "365", neverExpires, customExpiry, burnAfterReading), "expires", "This field must equal 1, 7, 365, never, custom or burn")
+
+	if form.Expires == customExpiry {
+		expires, err := time.Parse(expiresAtLayout, form.ExpiresAt)
+		if err != nil {
+			form.AddFieldError("expires_at", "This field must be a valid date and time")
+			return
+		}
+
+		form.CheckField(validator.Future(expires), "expires_at", "This field must be in the future")
+		form.CheckField(validator.Within(expires, maxExpiry), "expires_at", fmt.Sprintf("This field cannot be more than %d days from now", int(maxExpiry.Hours()/24)))
+	}
+}
+
+// Define a commentForm struct to hold the comment form data. File and Line
+// are left empty for general comments, and point at a line of the current
+// revision of the snippet for line comments.
+type commentForm struct {
+	File                string `form:"file"`
+	Line                int    `form:"line"`
 	Content             string `form:"content"`
-	Expires             int    `form:"expires"`
 	validator.Validator `form:"-"`
 }
 
-// Create a new UserSignupForm struct
-type userSignupForm struct {
-	Name                string `form:"name"`
-	Email               string `form:"email"`
-	Password            string `form:"password"`
-	validator.Validator `form:"."`
+// The validate() method checks the comment form data against a snippet.
+func (form *commentForm) validate(snippet models.Snippet) {
+	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Content, maxCommentChars), "content", fmt.Sprintf("This field cannot be more than %d characters long", maxCommentChars))
+
+	if form.File == "" && form.Line == 0 {
+		return
+	}
+
+	i := slices.IndexFunc(snippet.Files, func(f models.File) bool {
+		return f.Name == form.File
+	})
+	if i < 0 {
+		form.AddFieldError("file", "This field must be one of the files of the snippet")
+		return
+	}
+
+	lines := snippet.Files[i].LineCount()
+	form.CheckField(validator.Between(form.Line, 1, lines), "line", fmt.Sprintf("This field must be a line number between 1 and %d", lines))
+}
+
+// The line() method returns the line the comment is about, or the zero
+// LineRef for a general comment.
+func (form *commentForm) line(snippet models.Snippet) models.LineRef {
+	if form.File == "" {
+		return models.LineRef{}
+	}
+
+	return models.LineRef{Revision: snippet.Revision, FileName: form.File, Number: form.Line}
+}
+
+// The maximum length of a comment, in characters.
+const maxCommentChars = 2000
+
+// Define a collectionForm struct to hold the collection form data. Snippets
+// lists the IDs of the snippets of the collection in their new order, and
+// Remove those to take out of it. Both are only used when editing.
+type collectionForm struct {
+	Title               string `form:"title"`
+	Slug                string `form:"slug"`
+	Description         string `form:"description"`
+	Visibility          string `form:"visibility"`
+	Snippets            []int  `form:"snippets"`
+	Remove              []int  `form:"remove"`
+	validator.Validator `form:"-"`
+}
+
+// The validate() method checks the collection form data.
+func (form *collectionForm) validate() {
+	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
+	// Unlisted collections get a random slug instead of the one given.
+	if form.Visibility != models.VisibilityUnlisted {
+		form.CheckField(validator.MaxChars(form.Slug, 50), "slug", "This field cannot be more than 50 characters long")
+		form.CheckField(validator.Matches(form.Slug, validator.SlugRX), "slug", "This field must be lowercase words of letters and digits separated by hyphens")
+		// "create" would be shadowed by the /collection/create route.
+		form.CheckField(form.Slug != "create", "slug", "This slug is reserved")
+	}
+	form.CheckField(validator.MaxChars(form.Description, maxDescriptionChars), "description", fmt.Sprintf("This field cannot be more than %d characters long", maxDescriptionChars))
+	form.CheckField(validator.PermittedValue(form.Visibility, models.VisibilityPublic, models.VisibilityUnlisted, models.VisibilityPrivate), "visibility", "This field must equal public, unlisted or private")
+}
+
+// The snippetIDs() method returns the IDs of the snippets to keep in the
+// collection, in order.
+func (form *collectionForm) snippetIDs() []int {
+	var ids []int
+
+	for _, id := range form.Snippets {
+		if !slices.Contains(form.Remove, id) {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// The maximum length of a collection description, in characters.
+const maxDescriptionChars = 1000
+
+// Define a tokenForm struct to hold the API token form data. Expires is the
+// number of days for which the token is valid, or "never".
+type tokenForm struct {
+	Name                string `form:"name"`
+	Expires             string `form:"expires"`
+	validator.Validator `form:"-"`
+}
+
+// The validate() method checks the API token form data.
+func (form *tokenForm) validate() {
+	form.CheckField(validator.NotBlank(form.Name), "name", "This field cannot be blank")
+	form.CheckField(validator.MaxChars(form.Name, 100), "name", "This field cannot be more than 100 characters long")
+	form.CheckField(validator.PermittedValue(form.Expires, "30", "90", "365", neverExpires), "expires", "This field must equal 30, 90, 365 or never")
+}
+
+// The expiry() method returns the time at which the token expires, which is
+// zero if it never does. It must only be called on a validated form.
+func (form *tokenForm) expiry() time.Time {
+	if form.Expires == neverExpires {
+		return time.Time{}
+	}
+
+	days, _ := strconv.Atoi(form.Expires)
+	return time.Now().UTC().AddDate(0, 0, days)
+}
+
+// Create a new UserSignupForm struct
+type userSignupForm struct {
+	Name                string `form:"name"`
+	Email               string `form:"email"`
+	Password            string `form:"password"`
+	validator.Validator `form:"."`
+}
+
+// Create a new userLoginForm struct
+type userLoginForm struct {
+	Email               string `form:"email"`
+	Password            string `form:"password"`
+	validator.Validator `form:"."`
+}
+
+// home handler function
+func (app *application) home(w http.ResponseWriter, r *http.Request) {
+	// Restrict the home handler to the "/" url pattern
+	// also consider adding the restriction when registering the handler
+	// mux.HandleFunc("/{$}", home)
+	// https://gopherbuilders.com/articles/avoiding-catchall-root-route-golang-servemux
+
+	// if r.URL.Path != "/" {
+	// 	http.NotFound(w, r)
+	// 	return
+	// }
+
+	page, ok := readPage(r)
+	if !ok {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	snippets, total, err := app.snippets.List(page, snippetsPerPage)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	tagCloud, err := app.tags.Cloud(30)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	mostViewed, err := app.views.MostViewed(time.Now().AddDate(0, 0, -6), 5)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Call the newTemplateData() helper to get a templateData struct
+	// containing the 'default' data and add the snippets slice and the
+	// pagination details to it
+	data := app.newTemplateData(r)
+	data.Snippets = snippets
+	data.Pagination = newPagination(r, page, snippetsPerPage, total)
+	data.TagCloud = tagCloud
+	data.MostViewed = mostViewed
+
+	// Use the new render helper
+	app.render(w, r, http.StatusOK, "home.tmpl", data)
+}
+
+// snippetView: Display a specific snippet
+func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
+	// Use the viewableSnippet() helper to retrieve the snippet identified by
+	// the "id" wildcard, which may be a numeric ID or the slug of an unlisted
+	// snippet. If no matching record is found, or the current user isn't
+	// allowed to see it, return a 404 Not Found response
+	snippet, err := app.viewableSnippet(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Burn-after-reading snippets are deleted as soon as someone other than
+	// their owner sees them, so show a warning first and only reveal the
+	// content once the viewer confirms.
+	if snippet.BurnAfterReading && !app.isSnippetOwner(r, snippet) {
+		data := app.newTemplateData(r)
+		data.Snippet = snippet
+		app.render(w, r, http.StatusOK, "burn.tmpl", data)
+		return
+	}
+
+	app.countView(r, snippet)
+
+	data, err := app.snippetViewData(r, snippet)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+	data.Form = commentForm{}
+
+	// Use the new render helper
+	app.render(w, r, http.StatusOK, "view.tmpl", data)
+}
+
+// The snippetViewData() helper returns the template data for the snippet
+// view page, including the tags of the snippet, whether the current user
+// starred it, the collections it is in, and its comments. Comments
+// on a line of the current revision are shown next to that line, and the
+// rest, including comments on lines of earlier revisions, below the
+// snippet.
+func (app *application) snippetViewData(r *http.Request, snippet models.Snippet) (templateData, error) {
+	var err error
+
+	snippet.Tags, err = app.tags.ForSnippet(snippet.ID)
+	if err != nil {
+		return templateData{}, err
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+
+	// Link to the snippet this one was forked from if it is public or the
+	// user owns it. Anyone who can see the fork would otherwise be shown the
+	// slug of an unlisted parent, so those are only mentioned, and deleted or
+	// private parents are left out.
+	if snippet.ParentID != 0 {
+		parent, err := app.snippets.Get(snippet.ParentID)
+		switch {
+		case errors.Is(err, models.ErrNoRecord):
+		case err != nil:
+			return templateData{}, err
+		case parent.Visibility == models.VisibilityPublic || app.isSnippetOwner(r, parent):
+			data.Parent = parent
+		case parent.Visibility == models.VisibilityUnlisted:
+			data.UnlistedParent = true
+		}
+	}
+
+	if data.IsAuthenticated {
+		data.Starred, err = app.stars.Exists(data.AuthenticatedUserID, snippet.ID)
+		if err != nil {
+			return templateData{}, err
+		}
+
+		// Administrators can delete the snippets of other users, like
+		// requireSnippetOwnerOrAdmin allows, so offer them the link too.
+		if snippet.UserID != data.AuthenticatedUserID {
+			user, err := app.users.Get(data.AuthenticatedUserID)
+			if err != nil {
+				return templateData{}, err
+			}
+			data.IsAdmin = user.IsAdmin
+		}
+	}
+
+	data.Collections, err = app.collections.ForSnippet(snippet.ID)
+	if err != nil {
+		return templateData{}, err
+	}
+
+	// Offer to add the snippet to one of the collections of the user which
+	// it can be added to.
+	if data.IsAuthenticated {
+		data.UserCollections, err = app.collections.ListByUser(data.AuthenticatedUserID)
+		if err != nil {
+			return templateData{}, err
+		}
+
+		data.UserCollections = slices.DeleteFunc(data.UserCollections, func(c models.Collection) bool {
+			return !canCollect(c, snippet)
+		})
+	}
+
+	comments, err := app.comments.ForSnippet(snippet.ID)
+	if err != nil {
+		return templateData{}, err
+	}
+
+	data.LineComments = map[string]map[int][]models.Comment{}
+
+	for _, c := range comments {
+		if c.Line.IsZero() || c.Line.Revision != snippet.Revision {
+			data.Comments = append(data.Comments, c)
+			continue
+		}
+
+		if data.LineComments[c.Line.FileName] == nil {
+			data.LineComments[c.Line.FileName] = map[int][]models.Comment{}
+		}
+		data.LineComments[c.Line.FileName][c.Line.Number] = append(data.LineComments[c.Line.FileName][c.Line.Number], c)
+	}
+
+	return data, nil
+}
+
+// snippetRaw: Send the content of a file of a snippet as plain text
+func (app *application) snippetRaw(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.rawSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	file, ok := snippetFile(r, snippet)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(file.Content))
+}
+
+// snippetDownload: Send a file of a snippet as an attachment, or all of its
+// files as a zip archive
+func (app *application) snippetDownload(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.rawSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	if r.PathValue("file") != "" || len(snippet.Files) == 1 {
+		file, ok := snippetFile(r, snippet)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		// Use mime.FormatMediaType() so that the file name is quoted properly.
+		disposition := mime.FormatMediaType("attachment", map[string]string{"filename": file.Name})
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", disposition)
+		w.Write([]byte(file.Content))
+		return
+	}
+
+	// Build the archive in memory first, so that a server error response can
+	// still be sent if something goes wrong.
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+
+	for _, file := range snippet.Files {
+		fw, err := zw.Create(file.Name)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		_, err = fw.Write([]byte(file.Content))
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
+
+	err := zw.Close()
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": snippetArchiveName(snippet)})
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", disposition)
+	buf.WriteTo(w)
+}
+
+// snippetHistory: Display the list of revisions of a snippet
+func (app *application) snippetHistory(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.readableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	revisions, err := app.revisions.List(snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Revisions = revisions
+
+	app.render(w, r, http.StatusOK, "history.tmpl", data)
+}
+
+// The number of unchanged lines shown around each change in a diff.
+const diffContextLines = 3
+
+// Define a fileDiff type to hold the changes made to one file of a snippet
+// between two revisions. Files are matched by name, so a renamed file shows
+// up as deleted under its old name and added under its new one.
+type fileDiff struct {
+	Name        string
+	Status      string
+	OldLanguage string
+	NewLanguage string
+	Hunks       []diff.Hunk
+}
+
+// The diffFiles() helper compares the files of two revisions, returning the
+// files which changed: first those of the new revision in order, then those
+// which were deleted.
+func diffFiles(from, to []models.File) []fileDiff {
+	var diffs []fileDiff
+
+	for _, newFile := range to {
+		i := slices.IndexFunc(from, func(f models.File) bool { return f.Name == newFile.Name })
+		if i < 0 {
+			diffs = append(diffs, fileDiff{
+				Name:        newFile.Name,
+				Status:      "added",
+				NewLanguage: newFile.Language,
+				Hunks:       diff.Unified("", newFile.Content, diffContextLines),
+			})
+			continue
+		}
+
+		oldFile := from[i]
+		if oldFile == newFile {
+			continue
+		}
+
+		diffs = append(diffs, fileDiff{
+			Name:        newFile.Name,
+			Status:      "modified",
+			OldLanguage: oldFile.Language,
+			NewLanguage: newFile.Language,
+			Hunks:       diff.Unified(oldFile.Content, newFile.Content, diffContextLines),
+		})
+	}
+
+	for _, oldFile := range from {
+		if !slices.ContainsFunc(to, func(f models.File) bool { return f.Name == oldFile.Name }) {
+			diffs = append(diffs, fileDiff{
+				Name:        oldFile.Name,
+				Status:      "deleted",
+				OldLanguage: oldFile.Language,
+				Hunks:       diff.Unified(oldFile.Content, "", diffContextLines),
+			})
+		}
+	}
+
+	return diffs
+}
+
+// snippetDiff: Display the changes between two revisions of a snippet
+func (app *application) snippetDiff(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.readableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	// By default show the changes made by the current revision.
+	to, ok := readRevision(r, "to", snippet.Revision)
+	if !ok {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	from, ok := readRevision(r, "from", max(to-1, 1))
+	if !ok {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	fromRevision, err := app.revisions.Get(snippet.ID, from)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	toRevision, err := app.revisions.Get(snippet.ID, to)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.FromRevision = fromRevision
+	data.ToRevision = toRevision
+	data.Diff = diffFiles(fromRevision.Files, toRevision.Files)
+
+	app.render(w, r, http.StatusOK, "diff.tmpl", data)
+}
+
+// snippetReveal: Display a burn-after-reading snippet and delete it
+func (app *application) snippetReveal(w http.ResponseWriter, r *http.Request) {
+	snippet, err := app.viewableSnippet(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Owners can look at their burn-after-reading snippets without deleting
+	// them, and other snippets don't need revealing.
+	if !snippet.BurnAfterReading || app.isSnippetOwner(r, snippet) {
+		http.Redirect(w, r, snippetURL(snippet), http.StatusSeeOther)
+		return
+	}
+
+	// Burn() reads and deletes the snippet in one transaction, so if someone
+	// else got there first it returns ErrNoRecord.
+	snippet, err = app.snippets.Burn(snippet.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// The content can't be fetched again, so make sure that browsers and
+	// proxies don't keep a copy of it either.
+	w.Header().Set("Cache-Control", "no-store")
+
+	data := app.newTemplateData(r)
+	data.Snippet = snippet
+
+	app.render(w, r, http.StatusOK, "view.tmpl", data)
+}
+
+// search: Display the snippets matching the "q" query string parameter
+func (app *application) search(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.URL.Query().Get("q"))
+
+	page, ok := readPage(r)
+	if !ok {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.SearchQuery = query
+
+	// Only hit the database if there is something to search for.
+	if query != "" {
+		snippets, total, err := app.snippets.Search(query, page, snippetsPerPage)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+
+		data.Snippets = snippets
+		data.Pagination = newPagination(r, page, snippetsPerPage, total)
+	}
+
+	app.render(w, r, http.StatusOK, "search.tmpl", data)
+}
+
+// tagView: Display the snippets with a specific tag
+func (app *application) tagView(w http.ResponseWriter, r *http.Request) {
+	tag := r.PathValue("name")
+	if !validator.Matches(tag, validator.TagRX) {
+		http.NotFound(w, r)
+		return
+	}
+
+	page, ok := readPage(r)
+	if !ok {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	snippets, total, err := app.snippets.ListByTag(tag, page, snippetsPerPage)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tag = tag
+	data.Snippets = snippets
+	data.Pagination = newPagination(r, page, snippetsPerPage, total)
+
+	app.render(w, r, http.StatusOK, "tag.tmpl", data)
+}
+
+// userStars: Display the snippets starred by the current user
+func (app *application) userStars(w http.ResponseWriter, r *http.Request) {
+	page, ok := readPage(r)
+	if !ok {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	snippets, total, err := app.snippets.ListStarred(app.authenticatedUserID(r), page, snippetsPerPage)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Snippets = snippets
+	data.Pagination = newPagination(r, page, snippetsPerPage, total)
+
+	app.render(w, r, http.StatusOK, "stars.tmpl", data)
+}
+
+// getSnippetCreate: Display a form for creating a new snippet
+func (app application) getSnippetCreate(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+
+	// Initialize a new createSnippetForm instance, with a single empty file,
+	// and pass it to the template
+	data.Form = snippetCreateForm{
+		Files:      []snippetFileForm{{Language: syntax.PlainText}},
+		Visibility: models.VisibilityPublic,
+		Expires:    "365",
+	}
+
+	app.render(w, r, http.StatusOK, "create.tmpl", data)
+}
+
+// postSnippetCreate: Save a new snippet
+func (app *application) postSnippetCreate(w http.ResponseWriter, r *http.Request) {
+
+	// Declare a new empty instance of the snippetCreateForm struct.
+	var form snippetCreateForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	// Call the Decode() method on the form decoder, passing in the current
+	// request and *a pointerù to our snippetCreateForm struct. This will
+	// fill our struct with the relevant values from the HTML form.
+	// If there's a problem return a 400 Bad Request response to the client.
+	err = app.formDecoder.Decode(&form, r.PostForm)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+	}
+
+	// Execute our validation checks
+	form.validate(app.maxExpiry)
+
+	// Use the valid() method to see if any checks failed.
+
+	// If there are any validation errors, then re-display the create.tmpl template,
+	// passing in the postSnippetCreate instance as dynamic data in the form
+	// field. Note that we use the HTTP status code 422 Unprocessable Entity
+	// when sending the response to indicate that there was a validation error.
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "create.tmpl", data)
+		return
+	}
+
+	// Record the current user as the owner of the new snippet.
+	expires, burn := form.expiry()
+
+	id, err := app.snippets.Insert(app.authenticatedUserID(r), form.Title, form.files(), form.Visibility, expires, burn)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.tags.Set(id, form.tagNames())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Add flash message
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully created!")
+
+	app.redirectToSnippet(w, r, id)
+}
+
+// postSnippetFork: Copy a snippet into a new snippet owned by the current user
+func (app *application) postSnippetFork(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.readableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	// Private snippets are meant for their owner only, and burn-after-reading
+	// snippets must not outlive their first view, so neither can be forked.
+	if snippet.Visibility == models.VisibilityPrivate || snippet.BurnAfterReading {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	id, err := app.snippets.Fork(snippet.ID, app.authenticatedUserID(r))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// The fork starts out with the same tags as the original.
+	tags, err := app.tags.ForSnippet(snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.tags.Set(id, tags)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully forked!")
+
+	app.redirectToSnippet(w, r, id)
+}
+
+// postSnippetStar: Star a snippet on behalf of the current user. Starring a
+// snippet which is already starred has no effect.
+func (app *application) postSnippetStar(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.readableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	// Burn-after-reading snippets are gone before they could be found again.
+	if snippet.BurnAfterReading {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	err := app.stars.Insert(app.authenticatedUserID(r), snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully starred!")
+
+	http.Redirect(w, r, snippetURL(snippet), http.StatusSeeOther)
+}
+
+// postSnippetUnstar: Remove the star of the current user from a snippet, if
+// they had starred it
+func (app *application) postSnippetUnstar(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.readableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.stars.Delete(app.authenticatedUserID(r), snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully unstarred!")
+
+	http.Redirect(w, r, snippetURL(snippet), http.StatusSeeOther)
+}
+
+// postCommentCreate: Add a comment by the current user to a snippet
+func (app *application) postCommentCreate(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.readableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	// Burn-after-reading snippets don't stay around long enough to be
+	// discussed.
+	if snippet.BurnAfterReading {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	var form commentForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate(snippet)
+
+	// Show the snippet again, with the errors below the comment form.
+	if !form.Valid() {
+		data, err := app.snippetViewData(r, snippet)
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "view.tmpl", data)
+		return
+	}
+
+	id, err := app.comments.Insert(snippet.ID, app.authenticatedUserID(r), form.line(snippet), form.Content)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Comment successfully added!")
+
+	http.Redirect(w, r, fmt.Sprintf("%s#comment-%d", snippetURL(snippet), id), http.StatusSeeOther)
+}
+
+// postCommentDelete: Delete a comment, if the current user wrote it or owns
+// the snippet it was left on
+func (app *application) postCommentDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	comment, err := app.comments.Get(id)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	snippet, err := app.snippets.Get(comment.SnippetID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	if comment.UserID != app.authenticatedUserID(r) && !app.isSnippetOwner(r, snippet) {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	err = app.comments.Delete(comment.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Comment successfully deleted!")
+
+	http.Redirect(w, r, snippetURL(snippet)+"#comments", http.StatusSeeOther)
+}
+
+// postSnippetCollect: Add a snippet to one of the collections of the current
+// user
+func (app *application) postSnippetCollect(w http.ResponseWriter, r *http.Request) {
+	snippet, ok := app.readableSnippet(w, r)
+	if !ok {
+		return
+	}
+
+	// Collections are shared, so private and burn-after-reading snippets
+	// can't be added to them.
+	if snippet.Visibility == models.VisibilityPrivate || snippet.BurnAfterReading {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	err := r.ParseForm()
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	collections, err := app.collections.ListByUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Only the collections of the current user can be added to.
+	i := slices.IndexFunc(collections, func(c models.Collection) bool {
+		return strconv.Itoa(c.ID) == r.PostForm.Get("collection")
+	})
+	if i < 0 || !canCollect(collections[i], snippet) {
+		app.clientError(w, http.StatusForbidden)
+		return
+	}
+
+	err = app.collections.AddSnippet(collections[i].ID, snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", fmt.Sprintf("Snippet successfully added to %s!", collections[i].Title))
+
+	http.Redirect(w, r, snippetURL(snippet), http.StatusSeeOther)
+}
+
+// collectionView: Display a collection and its snippets
+func (app *application) collectionView(w http.ResponseWriter, r *http.Request) {
+	collection, err := app.collections.Get(r.PathValue("slug"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	// Private collections are only shown to their owner, and are reported
+	// as missing to everyone else.
+	if collection.Visibility == models.VisibilityPrivate && collection.UserID != app.authenticatedUserID(r) {
+		http.NotFound(w, r)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Collection = collection
+
+	app.render(w, r, http.StatusOK, "collection.tmpl", data)
 }
 
-// Create a new userLoginForm struct
-type userLoginForm struct {
-	Email               string `form:"email"`
-	Password            string `form:"password"`
-	validator.Validator `form:"."`
+// getCollectionCreate: Display a form for creating a new collection
+func (app *application) getCollectionCreate(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Form = collectionForm{
+		Visibility: models.VisibilityPublic,
+	}
+
+	app.render(w, r, http.StatusOK, "collection_create.tmpl", data)
 }
 
-// home handler function
-func (app *application) home(w http.ResponseWriter, r *http.Request) {
-	// Restrict the home handler to the "/" url pattern
-	// also consider adding the restriction when registering the handler
-	// mux.HandleFunc("/{$}", home)
-	// https://gopherbuilders.com/articles/avoiding-catchall-root-route-golang-servemux
+// postCollectionCreate: Save a new, empty collection
+func (app *application) postCollectionCreate(w http.ResponseWriter, r *http.Request) {
+	var form collectionForm
 
-	// if r.URL.Path != "/" {
-	// 	http.NotFound(w, r)
-	// 	return
-	// }
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "collection_create.tmpl", data)
+		return
+	}
+
+	if form.Visibility == models.VisibilityUnlisted {
+		form.Slug, err = models.NewSlug()
+		if err != nil {
+			app.serverError(w, r, err)
+			return
+		}
+	}
 
-	snippets, err := app.snippets.Latest()
+	// Slugs are unique, so show the form again if this one is taken.
+	_, err = app.collections.Insert(app.authenticatedUserID(r), form.Slug, form.Title, form.Description, form.Visibility)
 	if err != nil {
-		app.serverError(w, r, err)
+		if errors.Is(err, models.ErrDuplicateSlug) {
+			form.AddFieldError("slug", "This slug is already in use")
+			data := app.newTemplateData(r)
+			data.Form = form
+			app.render(w, r, http.StatusUnprocessableEntity, "collection_create.tmpl", data)
+		} else {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	// Call the newTemplateData() helper to get a templateData struct
-	// containing the 'default' data and add the snippets slice to it
+	app.sessionManager.Put(r.Context(), "flash", "Collection successfully created!")
+
+	http.Redirect(w, r, "/collection/"+form.Slug, http.StatusSeeOther)
+}
+
+// getCollectionEdit: Display a form for editing a collection of the current
+// user
+func (app *application) getCollectionEdit(w http.ResponseWriter, r *http.Request) {
+	collection, ok := app.ownCollection(w, r)
+	if !ok {
+		return
+	}
+
+	form := collectionForm{
+		Title:       collection.Title,
+		Slug:        collection.Slug,
+		Description: collection.Description,
+		Visibility:  collection.Visibility,
+	}
+	for _, s := range collection.Snippets {
+		form.Snippets = append(form.Snippets, s.ID)
+	}
+
 	data := app.newTemplateData(r)
-	data.Snippets = snippets
+	data.Collection = collection
+	data.Form = form
 
-	// Use the new render helper
-	app.render(w, r, http.StatusOK, "home.tmpl", data)
+	app.render(w, r, http.StatusOK, "collection_edit.tmpl", data)
 }
 
-// snippetView: Display a specific snippet
-func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
-	// Extract the value of the "id" wildcard from the request
-	// and sanitize it
-	id, err := strconv.Atoi(r.PathValue("id"))
-	if err != nil || id < 1 {
-		http.NotFound(w, r)
+// postCollectionEdit: Save the changes made to a collection of the current
+// user, including the order of its snippets
+func (app *application) postCollectionEdit(w http.ResponseWriter, r *http.Request) {
+	collection, ok := app.ownCollection(w, r)
+	if !ok {
 		return
 	}
 
-	// Use the SnippetModel.Get() method to retrieve the data for a
-	// specific record based on its ID. If no matching record is found,
-	// return a 404 Not Found response
-	snippet, err := app.snippets.Get(id)
+	var form collectionForm
+
+	err := app.decodePostForm(r, &form)
 	if err != nil {
-		if errors.Is(err, models.ErrNoRecord) {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+
+	// When the form is shown again, its snippets are listed in the order
+	// they were submitted in.
+	collection.Snippets = orderSnippets(collection.Snippets, form.Snippets)
+
+	if !form.Valid() {
+		data := app.newTemplateData(r)
+		data.Collection = collection
+		data.Form = form
+		app.render(w, r, http.StatusUnprocessableEntity, "collection_edit.tmpl", data)
+		return
+	}
+
+	// An unlisted collection keeps its random slug, and one which is made
+	// unlisted gets a new one, as its old slug might be known.
+	if form.Visibility == models.VisibilityUnlisted {
+		if collection.Visibility == models.VisibilityUnlisted {
+			form.Slug = collection.Slug
+		} else {
+			form.Slug, err = models.NewSlug()
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+		}
+	}
+
+	err = app.collections.Update(collection.ID, form.Slug, form.Title, form.Description, form.Visibility, form.snippetIDs())
+	if err != nil {
+		if errors.Is(err, models.ErrDuplicateSlug) {
+			form.AddFieldError("slug", "This slug is already in use")
+			data := app.newTemplateData(r)
+			data.Collection = collection
+			data.Form = form
+			app.render(w, r, http.StatusUnprocessableEntity, "collection_edit.tmpl", data)
+		} else if errors.Is(err, models.ErrNoRecord) {
 			http.NotFound(w, r)
 		} else {
 			app.serverError(w, r, err)
@@ -86,29 +1274,83 @@ func (app *application) snippetView(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	app.sessionManager.Put(r.Context(), "flash", "Collection successfully updated!")
+
+	http.Redirect(w, r, "/collection/"+form.Slug, http.StatusSeeOther)
+}
+
+// userCollections: Display the collections of the current user
+func (app *application) userCollections(w http.ResponseWriter, r *http.Request) {
+	collections, err := app.collections.ListByUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
 	data := app.newTemplateData(r)
-	data.Snippet = snippet
+	data.Collections = collections
 
-	// Use the new render helper
-	app.render(w, r, http.StatusOK, "view.tmpl", data)
+	app.render(w, r, http.StatusOK, "collections.tmpl", data)
 }
 
-// getSnippetCreate: Display a form for creating a new snippet
-func (app application) getSnippetCreate(w http.ResponseWriter, r *http.Request) {
+// getSnippetEdit: Display a form for editing an existing snippet
+func (app *application) getSnippetEdit(w http.ResponseWriter, r *http.Request) {
+	snippet := app.contextSnippet(r)
+
+	tags, err := app.tags.ForSnippet(snippet.ID)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
 	data := app.newTemplateData(r)
+	data.Snippet = snippet
+	data.Form = newSnippetEditForm(snippet, tags)
 
-	// Initialize a new createSnippetForm instance and pass it to the template
-	data.Form = snippetCreateForm{
-		Expires: 365,
+	app.render(w, r, http.StatusOK, "edit.tmpl", data)
+}
+
+// The newSnippetEditForm() helper returns a form pre-populated with the
+// current values of a snippet, including its current expiry date, so that
+// saving the snippet doesn't change it.
+func newSnippetEditForm(snippet models.Snippet, tags []string) snippetCreateForm {
+	form := snippetCreateForm{
+		Title:      snippet.Title,
+		Visibility: snippet.Visibility,
+		Tags:       strings.Join(tags, ", "),
+		Expires:    customExpiry,
+		ExpiresAt:  snippet.Expires.UTC().Format(expiresAtLayout),
+	}
+	if snippet.Expires.IsZero() {
+		form.Expires = neverExpires
+		form.ExpiresAt = ""
+	}
+	for _, f := range snippet.Files {
+		form.Files = append(form.Files, snippetFileForm{Name: f.Name, Language: f.Language, Content: f.Content})
+	}
+	if snippet.BurnAfterReading {
+		form.Expires = burnAfterReading
 	}
 
-	app.render(w, r, http.StatusOK, "create.tmpl", data)
+	return form
 }
 
-// postSnippetCreate: Save a new snippet
-func (app *application) postSnippetCreate(w http.ResponseWriter, r *http.Request) {
+// The sameExpiry() method reports whether the expires fields still hold the
+// expiry of a snippet, as filled in by newSnippetEditForm().
+func (form *snippetCreateForm) sameExpiry(snippet models.Snippet) bool {
+	current := newSnippetEditForm(snippet, nil)
+
+	if form.Expires != current.Expires {
+		return false
+	}
+
+	return form.Expires != customExpiry || form.ExpiresAt == current.ExpiresAt
+}
+
+// postSnippetEdit: Save the changes made to an existing snippet
+func (app *application) postSnippetEdit(w http.ResponseWriter, r *http.Request) {
+	snippet := app.contextSnippet(r)
 
-	// Declare a new empty instance of the snippetCreateForm struct.
 	var form snippetCreateForm
 
 	err := app.decodePostForm(r, &form)
@@ -117,45 +1359,76 @@ func (app *application) postSnippetCreate(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	// Call the Decode() method on the form decoder, passing in the current
-	// request and *a pointerù to our snippetCreateForm struct. This will
-	// fill our struct with the relevant values from the HTML form.
-	// If there's a problem return a 400 Bad Request response to the client.
-	err = app.formDecoder.Decode(&form, r.PostForm)
-	if err != nil {
-		app.clientError(w, http.StatusBadRequest)
-	}
-
-	// Use the embedded Validator struct's CheckField() method to execute our
-	// validation checks
-	form.CheckField(validator.NotBlank(form.Title), "title", "This field cannot be blank")
-	form.CheckField(validator.MaxChars(form.Title, 100), "title", "This field cannot be more than 100 characters long")
-	form.CheckField(validator.NotBlank(form.Content), "content", "This field cannot be blank")
-	form.CheckField(validator.PermittedValue(form.Expires, 1, 7, 365), "expires", "This field must equal 1, 7 or 365")
+	form.validate(app.maxExpiry)
 
-	// Use the valid() method to see if any checks failed.
+	// Leave the expiry alone unless the owner changes it, like the API does.
+	// Otherwise burn-after-reading snippets would be given another week, and
+	// checking the current expiry again would fail for snippets which are
+	// about to expire.
+	keepExpiry := form.sameExpiry(snippet)
+	if keepExpiry {
+		delete(form.FieldErrors, "expires_at")
+	}
 
-	// If there are any validation errors, then re-display the create.tmpl template,
-	// passing in the postSnippetCreate instance as dynamic data in the form
-	// field. Note that we use the HTTP status code 422 Unprocessable Entity
-	// when sending the response to indicate that there was a validation error.
 	if !form.Valid() {
 		data := app.newTemplateData(r)
+		data.Snippet = snippet
 		data.Form = form
-		app.render(w, r, http.StatusUnprocessableEntity, "create.tmpl", data)
+		app.render(w, r, http.StatusUnprocessableEntity, "edit.tmpl", data)
+		return
+	}
+
+	expires, burn := form.expiry()
+	if keepExpiry {
+		expires, burn = snippet.Expires, snippet.BurnAfterReading
+	}
+
+	err = app.snippets.Update(snippet.ID, app.authenticatedUserID(r), form.Title, form.files(), form.Visibility, expires, burn)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
 		return
 	}
 
-	id, err := app.snippets.Insert(form.Title, form.Content, form.Expires)
+	err = app.tags.Set(snippet.ID, form.tagNames())
 	if err != nil {
 		app.serverError(w, r, err)
 		return
 	}
 
-	// Add flash message
-	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully created!")
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully updated!")
+
+	app.redirectToSnippet(w, r, snippet.ID)
+}
 
-	http.Redirect(w, r, fmt.Sprintf("/snippet/view/%d", id), http.StatusSeeOther)
+// getSnippetDelete: Ask for confirmation before deleting a snippet
+func (app *application) getSnippetDelete(w http.ResponseWriter, r *http.Request) {
+	data := app.newTemplateData(r)
+	data.Snippet = app.contextSnippet(r)
+
+	app.render(w, r, http.StatusOK, "delete.tmpl", data)
+}
+
+// postSnippetDelete: Delete a snippet before it expires
+func (app *application) postSnippetDelete(w http.ResponseWriter, r *http.Request) {
+	snippet := app.contextSnippet(r)
+
+	err := app.snippets.Delete(snippet.ID)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Snippet successfully deleted!")
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
 func (app *application) getUserSignup(w http.ResponseWriter, r *http.Request) {
@@ -292,6 +1565,80 @@ func (app *application) postUserLogout(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/", http.StatusSeeOther)
 }
 
+// userTokens: Display the API tokens of the current user, and a form to
+// create a new one
+func (app *application) userTokens(w http.ResponseWriter, r *http.Request) {
+	app.renderTokens(w, r, http.StatusOK, tokenForm{Expires: "90"}, "")
+}
+
+// postTokenCreate: Create an API token and show it, for the only time
+func (app *application) postTokenCreate(w http.ResponseWriter, r *http.Request) {
+	var form tokenForm
+
+	err := app.decodePostForm(r, &form)
+	if err != nil {
+		app.clientError(w, http.StatusBadRequest)
+		return
+	}
+
+	form.validate()
+
+	if !form.Valid() {
+		app.renderTokens(w, r, http.StatusUnprocessableEntity, form, "")
+		return
+	}
+
+	token, err := app.tokens.Insert(app.authenticatedUserID(r), form.Name, form.expiry())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Render the page straight away rather than redirecting, so that the
+	// token isn't kept in the session.
+	app.renderTokens(w, r, http.StatusOK, tokenForm{Expires: "90"}, token)
+}
+
+// postTokenDelete: Revoke an API token of the current user
+func (app *application) postTokenDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		http.NotFound(w, r)
+		return
+	}
+
+	err = app.tokens.Delete(id, app.authenticatedUserID(r))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return
+	}
+
+	app.sessionManager.Put(r.Context(), "flash", "Token successfully revoked!")
+
+	http.Redirect(w, r, "/user/tokens", http.StatusSeeOther)
+}
+
+// The renderTokens() helper renders the API tokens page with the given form,
+// and the token which was just created, if any.
+func (app *application) renderTokens(w http.ResponseWriter, r *http.Request, status int, form tokenForm, newToken string) {
+	tokens, err := app.tokens.ListByUser(app.authenticatedUserID(r))
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	data := app.newTemplateData(r)
+	data.Tokens = tokens
+	data.NewToken = newToken
+	data.Form = form
+
+	app.render(w, r, status, "tokens.tmpl", data)
+}
+
 func ping(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("OK"))
 }
@@ -1,10 +1,16 @@
 package main
 
 import (
+	"archive/zip"
 	"net/http"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Overlrd/snippetbox/internal/assert"
+	"github.com/Overlrd/snippetbox/internal/models"
+	"github.com/Overlrd/snippetbox/internal/models/mocks"
 )
 
 func TestPing(t *testing.T) {
@@ -19,6 +25,182 @@ func TestPing(t *testing.T) {
 	assert.Equal(t, body, "OK")
 }
 
+func TestHome(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "First page",
+			urlPath:  "/",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond",
+		},
+		{
+			name:     "Explicit page",
+			urlPath:  "/?page=1",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond",
+		},
+		{
+			name:     "Tag cloud",
+			urlPath:  "/",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/tag/haiku' class='tag weight-5'>haiku</a>",
+		},
+		{
+			name:     "Star counts",
+			urlPath:  "/",
+			wantCode: http.StatusOK,
+			wantBody: "<th>Stars</th>",
+		},
+		{
+			name:     "Most viewed",
+			urlPath:  "/",
+			wantCode: http.StatusOK,
+			wantBody: "<li><a href='/snippet/view/1'>An old silent pond</a> <small>12 views</small></li>",
+		},
+		{
+			name:     "Out of range page",
+			urlPath:  "/?page=2",
+			wantCode: http.StatusOK,
+			wantBody: "There's nothing to see here yet!",
+		},
+		{
+			name:     "Zero page",
+			urlPath:  "/?page=0",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "String page",
+			urlPath:  "/?page=foo",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSearch(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Matching query",
+			urlPath:  "/search?q=silent",
+			wantCode: http.StatusOK,
+			wantBody: "An old <mark>silent</mark> pond",
+		},
+		{
+			name:     "No matches",
+			urlPath:  "/search?q=toad",
+			wantCode: http.StatusOK,
+			wantBody: "No snippets match your search.",
+		},
+		{
+			name:     "Blank query",
+			urlPath:  "/search?q=+",
+			wantCode: http.StatusOK,
+			wantBody: "Enter some words to search",
+		},
+		{
+			name:     "Invalid page",
+			urlPath:  "/search?q=silent&page=foo",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestTagView(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Tag with snippets",
+			urlPath:  "/tag/haiku",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond",
+		},
+		{
+			name:     "Tag without snippets",
+			urlPath:  "/tag/k8s",
+			wantCode: http.StatusOK,
+			wantBody: "There are no snippets with this tag.",
+		},
+		{
+			name:     "Tag with a hash",
+			urlPath:  "/tag/c%23",
+			wantCode: http.StatusOK,
+			wantBody: "Snippets tagged &ldquo;c#&rdquo;",
+		},
+		{
+			name:     "Tag with pluses",
+			urlPath:  "/tag/c++",
+			wantCode: http.StatusOK,
+			wantBody: "Snippets tagged &ldquo;c&#43;&#43;&rdquo;",
+		},
+		{
+			name:     "Invalid tag",
+			urlPath:  "/tag/Not%20A%20Tag",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
 func TestSnippetView(t *testing.T) {
 	// Create a new instance of our application struct which uses the mocked
 	// dependencies.
@@ -42,11 +224,98 @@ func TestSnippetView(t *testing.T) {
 			wantCode: http.StatusOK,
 			wantBody: "An old silent pond...",
 		},
+		{
+			name:     "Shows every file",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='#file-frog.txt'>frog.txt</a>",
+		},
+		{
+			name:     "Numbers the lines of the first file",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "<td class='number'><a href='#L1'>1</a></td>",
+		},
+		{
+			name:     "Numbers the lines of other files",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "<tr id='frog.txt-L2'>",
+		},
+		{
+			name:     "Shows star count",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "1 fork, 1 star",
+		},
+		{
+			name:     "Shows view count",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "42 views",
+		},
+		{
+			name:     "Renders Markdown",
+			urlPath:  "/snippet/view/6",
+			wantCode: http.StatusOK,
+			wantBody: "<div class='markdown'><h1>Deploying</h1>",
+		},
+		{
+			name:     "Highlights fenced code in Markdown",
+			urlPath:  "/snippet/view/6",
+			wantCode: http.StatusOK,
+			wantBody: `<pre class="chroma"><code><span class="kd">func</span>`,
+		},
+		{
+			name:     "Leaves raw HTML out of Markdown",
+			urlPath:  "/snippet/view/6",
+			wantCode: http.StatusOK,
+			wantBody: "<p>Run <!-- raw HTML omitted -->alert(1)<!-- raw HTML omitted --> this:</p>",
+		},
+		{
+			name:     "Keeps the source of Markdown",
+			urlPath:  "/snippet/view/6",
+			wantCode: http.StatusOK,
+			wantBody: "<summary>Source</summary>",
+		},
+		{
+			name:     "Shows author",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "by Alice",
+		},
+		{
+			name:     "Shows tags",
+			urlPath:  "/snippet/view/1",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/tag/poetry' class='tag'>poetry</a>",
+		},
 		{
 			name:     "Non-existent ID",
 			urlPath:  "/snippet/view/2",
 			wantCode: http.StatusNotFound,
 		},
+		{
+			name:     "Unlisted by slug",
+			urlPath:  "/snippet/view/dW5saXN0ZWQtc25pcHBldDM",
+			wantCode: http.StatusOK,
+			wantBody: "Over the wintry forest",
+		},
+		{
+			name:     "Unlisted by ID",
+			urlPath:  "/snippet/view/3",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private by ID",
+			urlPath:  "/snippet/view/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private by slug",
+			urlPath:  "/snippet/view/cHJpdmF0ZS1zbmlwcGV0LTQ",
+			wantCode: http.StatusNotFound,
+		},
 		{
 			name:     "Negative ID",
 			urlPath:  "/snippet/view/-1",
@@ -75,3 +344,2229 @@ func TestSnippetView(t *testing.T) {
 		})
 	}
 }
+
+func TestSnippetViewCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		urlPaths  []string
+		wantViews map[int]int
+	}{
+		{
+			name:      "Anonymous",
+			urlPaths:  []string{"/snippet/view/1"},
+			wantViews: map[int]int{1: 1},
+		},
+		{
+			name:      "Repeated in the same session",
+			urlPaths:  []string{"/snippet/view/1", "/snippet/view/1", "/snippet/view/dW5saXN0ZWQtc25pcHBldDM", "/snippet/view/1"},
+			wantViews: map[int]int{1: 1, 3: 1},
+		},
+		{
+			name:      "Another user",
+			email:     "bob@example.com",
+			urlPaths:  []string{"/snippet/view/1", "/snippet/view/1"},
+			wantViews: map[int]int{1: 1},
+		},
+		{
+			name:      "Owner",
+			email:     "alice@example.com",
+			urlPaths:  []string{"/snippet/view/1"},
+			wantViews: map[int]int{},
+		},
+		{
+			name:      "Not found",
+			urlPaths:  []string{"/snippet/view/2"},
+			wantViews: map[int]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			if tt.email != "" {
+				ts.login(t, tt.email, "password")
+			}
+
+			for _, urlPath := range tt.urlPaths {
+				ts.get(t, urlPath)
+			}
+
+			assert.Equal(t, len(app.viewCounter.counts), len(tt.wantViews))
+			for id, n := range tt.wantViews {
+				assert.Equal(t, app.viewCounter.counts[id], n)
+			}
+		})
+	}
+}
+
+func TestSnippetViewVisibility(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Owner sees unlisted by ID",
+			email:    "alice@example.com",
+			urlPath:  "/snippet/view/3",
+			wantCode: http.StatusOK,
+			wantBody: "/snippet/view/dW5saXN0ZWQtc25pcHBldDM",
+		},
+		{
+			name:     "Owner sees private",
+			email:    "alice@example.com",
+			urlPath:  "/snippet/view/4",
+			wantCode: http.StatusOK,
+			wantBody: "First autumn morning",
+		},
+		{
+			name:     "Other user cannot see private",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/view/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Other user cannot see unlisted by ID",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/view/3",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			ts.login(t, tt.email, "password")
+
+			code, _, body := ts.get(t, tt.urlPath)
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSnippetBurn(t *testing.T) {
+	app := newTestApplication(t)
+
+	const burnURL = "/snippet/view/YnVybi1zbmlwcGV0LWZpdmU"
+
+	t.Run("Interstitial hides content", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, _, body := ts.get(t, burnURL)
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "will be deleted as soon as you view it")
+		assert.Equal(t, strings.Contains(body, "correct horse battery staple"), false)
+	})
+
+	t.Run("Reveal", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, burnURL)
+
+		form := url.Values{}
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, body := ts.postForm(t, burnURL, form)
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, headers.Get("Cache-Control"), "no-store")
+		assert.StringContains(t, body, "correct horse battery staple")
+		assert.StringContains(t, body, "This snippet has now been deleted.")
+	})
+
+	t.Run("Reveal ordinary snippet", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, burnURL)
+
+		form := url.Values{}
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, _ := ts.postForm(t, "/snippet/view/1", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/snippet/view/1")
+	})
+
+	t.Run("Owner sees content", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "alice@example.com", "password")
+
+		code, _, body := ts.get(t, "/snippet/view/5")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "correct horse battery staple")
+		assert.StringContains(t, body, "will be deleted the first time someone else views it")
+	})
+}
+
+func TestSnippetRaw(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name             string
+		urlPath          string
+		wantCode         int
+		wantBody         string
+		wantCacheControl string
+	}{
+		{
+			name:             "Public snippet",
+			urlPath:          "/snippet/raw/1",
+			wantCode:         http.StatusOK,
+			wantBody:         "An old silent pond...",
+			wantCacheControl: "public, max-age=0",
+		},
+		{
+			name:             "Named file",
+			urlPath:          "/snippet/raw/1/frog.txt",
+			wantCode:         http.StatusOK,
+			wantBody:         "A frog jumps into the pond",
+			wantCacheControl: "public, max-age=0",
+		},
+		{
+			name:     "Non-existent file",
+			urlPath:  "/snippet/raw/1/toad.txt",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:             "Unlisted snippet by slug",
+			urlPath:          "/snippet/raw/dW5saXN0ZWQtc25pcHBldDM",
+			wantCode:         http.StatusOK,
+			wantBody:         "Over the wintry forest",
+			wantCacheControl: "private, no-store",
+		},
+		{
+			name:     "Unlisted snippet by ID",
+			urlPath:  "/snippet/raw/3",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private snippet",
+			urlPath:  "/snippet/raw/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Burn after reading snippet",
+			urlPath:  "/snippet/raw/YnVybi1zbmlwcGV0LWZpdmU",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			urlPath:  "/snippet/raw/2",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, headers, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantCode == http.StatusOK {
+				assert.Equal(t, headers.Get("Content-Type"), "text/plain; charset=utf-8")
+				assert.Equal(t, headers.Get("Cache-Control"), tt.wantCacheControl)
+				assert.StringContains(t, body, tt.wantBody)
+				assert.Equal(t, strings.Contains(body, "<html"), false)
+			}
+		})
+	}
+
+	// Shared caches could otherwise store the cookies of the first visitor.
+	t.Run("No cookies", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		for _, urlPath := range []string{"/snippet/raw/1", "/snippet/download/1"} {
+			code, headers, _ := ts.get(t, urlPath)
+
+			assert.Equal(t, code, http.StatusOK)
+			assert.Equal(t, headers.Get("Set-Cookie"), "")
+		}
+	})
+}
+
+func TestSnippetDownload(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Single file", func(t *testing.T) {
+		code, headers, body := ts.get(t, "/snippet/download/1/frog.txt")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, headers.Get("Content-Type"), "text/plain; charset=utf-8")
+		assert.Equal(t, headers.Get("Content-Disposition"), "attachment; filename=frog.txt")
+		assert.StringContains(t, body, "A frog jumps into the pond")
+	})
+
+	t.Run("Snippet with one file", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/snippet/download/dW5saXN0ZWQtc25pcHBldDM")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, headers.Get("Content-Disposition"), "attachment; filename=forest.txt")
+	})
+
+	t.Run("All files", func(t *testing.T) {
+		code, headers, body := ts.get(t, "/snippet/download/1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.Equal(t, headers.Get("Content-Type"), "application/zip")
+		assert.Equal(t, headers.Get("Content-Disposition"), "attachment; filename=an-old-silent-pond.zip")
+
+		zr, err := zip.NewReader(strings.NewReader(body), int64(len(body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var names []string
+		for _, f := range zr.File {
+			names = append(names, f.Name)
+		}
+		assert.Equal(t, strings.Join(names, ","), "pond.txt,frog.txt")
+	})
+
+	t.Run("Non-existent file", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/snippet/download/1/toad.txt")
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+}
+
+func TestSnippetHistory(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody []string
+	}{
+		{
+			name:     "Public snippet",
+			urlPath:  "/snippet/1/history",
+			wantCode: http.StatusOK,
+			wantBody: []string{
+				"History of <a href='/snippet/view/1'>An old silent pond</a>",
+				"<a href='/snippet/1/diff?to=2'>#2</a>",
+				"<td>An old pond</td>",
+				"<td>Alice</td>",
+			},
+		},
+		{
+			name:     "Private snippet",
+			urlPath:  "/snippet/4/history",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Burn after reading snippet",
+			urlPath:  "/snippet/YnVybi1zbmlwcGV0LWZpdmU/history",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Unknown page",
+			urlPath:  "/snippet/1/versions",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			for _, want := range tt.wantBody {
+				assert.StringContains(t, body, want)
+			}
+		})
+	}
+}
+
+func TestSnippetDiff(t *testing.T) {
+	app := newTestApplication(t)
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		urlPath  string
+		wantCode int
+		wantBody []string
+	}{
+		{
+			name:     "Current revision",
+			urlPath:  "/snippet/1/diff",
+			wantCode: http.StatusOK,
+			wantBody: []string{
+				"Revision 1 &rarr; 2",
+				"Title changed from &ldquo;An old pond&rdquo; to &ldquo;An old silent pond&rdquo;",
+				"<span class='hunk'>@@ -1,1 &#43;1,1 @@</span>",
+				"<span class='delete'>-An old pond...</span>",
+				"<span class='insert'>&#43;An old silent pond...</span>",
+			},
+		},
+		{
+			name:     "Reversed",
+			urlPath:  "/snippet/1/diff?from=2&to=1",
+			wantCode: http.StatusOK,
+			wantBody: []string{
+				"<span class='delete'>-An old silent pond...</span>",
+				"<span class='insert'>&#43;An old pond...</span>",
+			},
+		},
+		{
+			name:     "Same revision",
+			urlPath:  "/snippet/1/diff?from=2&to=2",
+			wantCode: http.StatusOK,
+			wantBody: []string{"The files are the same in both revisions."},
+		},
+		{
+			name:     "Non-existent revision",
+			urlPath:  "/snippet/1/diff?from=1&to=3",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid revision",
+			urlPath:  "/snippet/1/diff?from=foo",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Negative revision",
+			urlPath:  "/snippet/1/diff?to=-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Private snippet",
+			urlPath:  "/snippet/4/diff",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			for _, want := range tt.wantBody {
+				assert.StringContains(t, body, want)
+			}
+		})
+	}
+}
+
+func TestSnippetCreate(t *testing.T) {
+	// The new snippet is fetched again for the URL of its page, which for
+	// unlisted snippets includes their slug.
+	app := newTestApplication(t)
+	app.snippets = &createdSnippetModel{}
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/snippet/create")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	csrfToken := ts.login(t, "alice@example.com", "password")
+
+	tests := []struct {
+		name         string
+		title        string
+		content      string
+		language     string
+		extraFiles   map[string]string
+		tags         string
+		expires      string
+		expiresAt    string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Valid submission",
+			title:        "O snail",
+			content:      "O snail\nClimb Mount Fuji,\nBut slowly, slowly!",
+			language:     "text",
+			tags:         "haiku, Poetry haiku",
+			expires:      "7",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI",
+		},
+		{
+			name:     "Invalid language",
+			title:    "O snail",
+			content:  "O snail",
+			language: "klingon",
+			expires:  "7",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field must be one of the listed languages",
+		},
+		{
+			name:     "Too many tags",
+			title:    "O snail",
+			content:  "O snail",
+			language: "text",
+			tags:     "a b c d e f",
+			expires:  "7",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot contain more than 5 tags",
+		},
+		{
+			name:     "Invalid tag",
+			title:    "O snail",
+			content:  "O snail",
+			language: "text",
+			tags:     "haiku <script>",
+			expires:  "7",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Tags must be at most 30 characters long",
+		},
+		{
+			name:     "Empty title",
+			title:    "",
+			content:  "O snail",
+			expires:  "7",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be blank",
+		},
+		{
+			name:     "Invalid expires",
+			title:    "O snail",
+			content:  "O snail",
+			expires:  "30",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field must equal 1, 7, 365, never, custom or burn",
+		},
+		{
+			name:     "Several files",
+			title:    "Deployment",
+			content:  "FROM golang:1.25",
+			language: "docker",
+			extraFiles: map[string]string{
+				"files[1].name":     "deploy.sh",
+				"files[1].language": "bash",
+				"files[1].content":  "docker build .",
+				// Blocks left empty are ignored, even with gaps in the numbering.
+				"files[3].language": "text",
+			},
+			expires:      "7",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI",
+		},
+		{
+			name:     "Duplicate file names",
+			title:    "Deployment",
+			content:  "docker build .",
+			language: "bash",
+			extraFiles: map[string]string{
+				"files[0].name":     "deploy.sh",
+				"files[1].name":     "deploy.sh",
+				"files[1].language": "bash",
+				"files[1].content":  "docker push",
+			},
+			expires:  "7",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "Each file must have a different name",
+		},
+		{
+			name:     "Invalid file name",
+			title:    "Deployment",
+			content:  "docker build .",
+			language: "bash",
+			extraFiles: map[string]string{
+				"files[0].name": "../deploy.sh",
+			},
+			expires:  "7",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "File names can contain only letters, digits and . _ -",
+		},
+		{
+			name:         "Burn after reading",
+			title:        "Database password",
+			content:      "correct horse battery staple",
+			language:     "text",
+			expires:      "burn",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI",
+		},
+		{
+			name:         "Never expires",
+			title:        "O snail",
+			content:      "O snail",
+			language:     "text",
+			expires:      "never",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI",
+		},
+		{
+			name:         "Custom expiry",
+			title:        "O snail",
+			content:      "O snail",
+			language:     "text",
+			expires:      "custom",
+			expiresAt:    time.Now().UTC().AddDate(0, 1, 0).Format("2006-01-02T15:04"),
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI",
+		},
+		{
+			name:      "Custom expiry in the past",
+			title:     "O snail",
+			content:   "O snail",
+			language:  "text",
+			expires:   "custom",
+			expiresAt: time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02T15:04"),
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field must be in the future",
+		},
+		{
+			name:      "Custom expiry too far away",
+			title:     "O snail",
+			content:   "O snail",
+			language:  "text",
+			expires:   "custom",
+			expiresAt: time.Now().UTC().AddDate(2, 0, 0).Format("2006-01-02T15:04"),
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field cannot be more than 365 days from now",
+		},
+		{
+			name:      "Invalid custom expiry",
+			title:     "O snail",
+			content:   "O snail",
+			language:  "text",
+			expires:   "custom",
+			expiresAt: "tomorrow",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field must be a valid date and time",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("files[0].content", tt.content)
+			form.Add("files[0].language", tt.language)
+			for key, value := range tt.extraFiles {
+				form.Set(key, value)
+			}
+			form.Add("visibility", "unlisted")
+			form.Add("tags", tt.tags)
+			form.Add("expires", tt.expires)
+			form.Add("expires_at", tt.expiresAt)
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, body := ts.postForm(t, "/snippet/create", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestSnippetFork(t *testing.T) {
+	app := newTestApplication(t)
+	app.snippets = &createdSnippetModel{}
+
+	tests := []struct {
+		name         string
+		email        string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Public snippet",
+			email:        "bob@example.com",
+			urlPath:      "/snippet/fork/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI",
+		},
+		{
+			name:         "Unlisted snippet by slug",
+			email:        "bob@example.com",
+			urlPath:      "/snippet/fork/dW5saXN0ZWQtc25pcHBldDM",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI",
+		},
+		{
+			name:     "Private snippet of another user",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/fork/4",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Own private snippet",
+			email:    "alice@example.com",
+			urlPath:  "/snippet/fork/4",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Burn after reading snippet",
+			email:    "alice@example.com",
+			urlPath:  "/snippet/fork/5",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/fork/99",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := ts.login(t, tt.email, "password")
+
+			form := url.Values{}
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/user/login")
+
+		form := url.Values{}
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, _ := ts.postForm(t, "/snippet/fork/1", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	t.Run("View page", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "bob@example.com", "password")
+
+		_, _, body := ts.get(t, "/snippet/view/1")
+		assert.StringContains(t, body, "<form action='/snippet/fork/1' method='POST'>")
+		assert.StringContains(t, body, "revision 2, 1 fork")
+
+		_, _, body = ts.get(t, "/snippet/view/dW5saXN0ZWQtc25pcHBldDM")
+		assert.StringContains(t, body, "<form action='/snippet/fork/dW5saXN0ZWQtc25pcHBldDM' method='POST'>")
+		assert.StringContains(t, body, "forked from <a href='/snippet/view/1'>#1</a>")
+	})
+}
+
+// The forkSnippetModel type behaves like the mock snippet model, with an
+// extra public snippet 7 forked from the snippet with the given ID.
+type forkSnippetModel struct {
+	mocks.SnippetModel
+	parentID int
+}
+
+func (m *forkSnippetModel) Get(id int) (models.Snippet, error) {
+	if id == 7 {
+		return models.Snippet{
+			ID:         7,
+			UserID:     2,
+			UserName:   "Bob",
+			ParentID:   m.parentID,
+			Title:      "A fork",
+			Files:      []models.File{{Name: "fork.txt", Language: "text", Content: "Forked!"}},
+			Visibility: models.VisibilityPublic,
+			Revision:   1,
+		}, nil
+	}
+
+	return m.SnippetModel.Get(id)
+}
+
+func TestSnippetViewParent(t *testing.T) {
+	tests := []struct {
+		name     string
+		parentID int
+		email    string
+		wantLink string
+	}{
+		{
+			name:     "Public parent",
+			parentID: 1,
+			wantLink: "forked from <a href='/snippet/view/1'>#1</a>",
+		},
+		{
+			name:     "Unlisted parent of a public fork",
+			parentID: 3,
+			wantLink: "<small>forked from an unlisted snippet</small>",
+		},
+		{
+			name:     "Unlisted parent of the viewer",
+			parentID: 3,
+			email:    "alice@example.com",
+			wantLink: "forked from <a href='/snippet/view/dW5saXN0ZWQtc25pcHBldDM'>#3</a>",
+		},
+		{
+			name:     "Private parent",
+			parentID: 4,
+		},
+		{
+			name:     "Private parent of the viewer",
+			parentID: 4,
+			email:    "alice@example.com",
+			wantLink: "forked from <a href='/snippet/view/4'>#4</a>",
+		},
+		{
+			name:     "Deleted parent",
+			parentID: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.snippets = &forkSnippetModel{parentID: tt.parentID}
+
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			if tt.email != "" {
+				ts.login(t, tt.email, "password")
+			}
+
+			code, _, body := ts.get(t, "/snippet/view/7")
+			assert.Equal(t, code, http.StatusOK)
+
+			if tt.wantLink != "" {
+				assert.StringContains(t, body, tt.wantLink)
+			} else {
+				assert.Equal(t, strings.Contains(body, "forked from"), false)
+			}
+
+			// The slug of an unlisted parent is only given to its owner.
+			if tt.email == "" {
+				assert.Equal(t, strings.Contains(body, "dW5saXN0ZWQtc25pcHBldDM"), false)
+			}
+		})
+	}
+}
+
+func TestSnippetViewDeleteLink(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		wantLink bool
+	}{
+		{
+			name:     "Owner",
+			email:    "alice@example.com",
+			wantLink: true,
+		},
+		{
+			name:     "Admin",
+			email:    "carol@example.com",
+			wantLink: true,
+		},
+		{
+			name:     "Other user",
+			email:    "bob@example.com",
+			wantLink: false,
+		},
+		{
+			name:     "Anonymous",
+			wantLink: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			if tt.email != "" {
+				ts.login(t, tt.email, "password")
+			}
+
+			code, _, body := ts.get(t, "/snippet/view/1")
+
+			assert.Equal(t, code, http.StatusOK)
+			assert.Equal(t, strings.Contains(body, "<a href='/snippet/delete/1'>Delete snippet</a>"), tt.wantLink)
+		})
+	}
+}
+
+// The updatedSnippetModel type records the expiry given to Update().
+type updatedSnippetModel struct {
+	mocks.SnippetModel
+	expires time.Time
+	burn    bool
+}
+
+func (m *updatedSnippetModel) Update(id int, userID int, title string, files []models.File, visibility string, expires time.Time, burn bool) error {
+	m.expires, m.burn = expires, burn
+	return nil
+}
+
+func TestSnippetEdit(t *testing.T) {
+	app := newTestApplication(t)
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, headers, _ := ts.get(t, "/snippet/edit/1")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	t.Run("Not the owner", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		csrfToken := ts.login(t, "bob@example.com", "password")
+
+		code, _, _ := ts.get(t, "/snippet/edit/1")
+		assert.Equal(t, code, http.StatusNotFound)
+
+		form := url.Values{}
+		form.Add("title", "Hijacked")
+		form.Add("files[0].content", "Hijacked")
+		form.Add("expires", "7")
+		form.Add("csrf_token", csrfToken)
+
+		code, _, _ = ts.postForm(t, "/snippet/edit/1", form)
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	csrfToken := ts.login(t, "alice@example.com", "password")
+
+	t.Run("Owner", func(t *testing.T) {
+		code, _, body := ts.get(t, "/snippet/edit/1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "An old silent pond...")
+		// The current expiry date is kept unless the owner changes it.
+		assert.StringContains(t, body, `<input type="radio" name="expires" value="custom" checked />`)
+	})
+
+	t.Run("Non-existent ID", func(t *testing.T) {
+		code, _, _ := ts.get(t, "/snippet/edit/2")
+
+		assert.Equal(t, code, http.StatusNotFound)
+	})
+
+	tests := []struct {
+		name         string
+		title        string
+		content      string
+		expires      string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Valid submission",
+			title:        "An old silent pond",
+			content:      "An old silent pond...\nA frog jumps into the pond,",
+			expires:      "365",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:     "Empty content",
+			title:    "An old silent pond",
+			content:  "",
+			expires:  "365",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be blank",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("files[0].name", "pond.txt")
+			form.Add("files[0].content", tt.content)
+			form.Add("files[0].language", "text")
+			form.Add("visibility", "unlisted")
+			form.Add("expires", tt.expires)
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, body := ts.postForm(t, "/snippet/edit/1", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+	t.Run("Unchanged expiry is kept", func(t *testing.T) {
+		snippets := &updatedSnippetModel{}
+		app := newTestApplication(t)
+		app.snippets = snippets
+
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		csrfToken := ts.login(t, "alice@example.com", "password")
+
+		snippet, err := snippets.Get(5)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		form := url.Values{}
+		form.Add("title", "Database password")
+		form.Add("files[0].name", "password.txt")
+		form.Add("files[0].content", "correct horse battery staple")
+		form.Add("files[0].language", "text")
+		form.Add("visibility", "unlisted")
+		form.Add("expires", "burn")
+		form.Add("expires_at", snippet.Expires.UTC().Format(expiresAtLayout))
+		form.Add("csrf_token", csrfToken)
+
+		code, _, _ := ts.postForm(t, "/snippet/edit/5", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, snippets.expires, snippet.Expires)
+		assert.Equal(t, snippets.burn, true)
+	})
+}
+
+func TestSnippetDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name         string
+		email        string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Owner",
+			email:        "alice@example.com",
+			urlPath:      "/snippet/delete/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/",
+		},
+		{
+			name:     "Not the owner",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/delete/1",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:         "Admin",
+			email:        "carol@example.com",
+			urlPath:      "/snippet/delete/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/",
+		},
+		{
+			name:     "Non-existent ID",
+			email:    "alice@example.com",
+			urlPath:  "/snippet/delete/2",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := ts.login(t, tt.email, "password")
+
+			form := url.Values{}
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	t.Run("Confirmation page", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "alice@example.com", "password")
+
+		code, _, body := ts.get(t, "/snippet/delete/1")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "Are you sure you want to delete")
+	})
+
+	t.Run("Confirmation page of an unlisted snippet", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "alice@example.com", "password")
+
+		_, _, body := ts.get(t, "/snippet/delete/3")
+
+		assert.StringContains(t, body, "<a href=\"/snippet/view/dW5saXN0ZWQtc25pcHBldDM\">Cancel</a>")
+	})
+}
+
+func TestSnippetStar(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name         string
+		email        string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+		wantFlash    string
+	}{
+		{
+			name:         "Star",
+			email:        "alice@example.com",
+			urlPath:      "/snippet/1/star",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+			wantFlash:    "Snippet successfully starred!",
+		},
+		{
+			name:         "Star unlisted snippet by slug",
+			email:        "alice@example.com",
+			urlPath:      "/snippet/dW5saXN0ZWQtc25pcHBldDM/star",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/dW5saXN0ZWQtc25pcHBldDM",
+			wantFlash:    "Snippet successfully starred!",
+		},
+		{
+			name:         "Star already starred snippet",
+			email:        "bob@example.com",
+			urlPath:      "/snippet/1/star",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+			wantFlash:    "Snippet successfully starred!",
+		},
+		{
+			name:         "Unstar",
+			email:        "bob@example.com",
+			urlPath:      "/snippet/1/unstar",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+			wantFlash:    "Snippet successfully unstarred!",
+		},
+		{
+			name:         "Unstar snippet which isn't starred",
+			email:        "alice@example.com",
+			urlPath:      "/snippet/1/unstar",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+			wantFlash:    "Snippet successfully unstarred!",
+		},
+		{
+			name:     "Unstar private snippet of another user",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/4/unstar",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Star private snippet of another user",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/4/star",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Star burn after reading snippet",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/YnVybi1zbmlwcGV0LWZpdmU/star",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Star non-existent ID",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/2/star",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Unknown action",
+			email:    "bob@example.com",
+			urlPath:  "/snippet/1/like",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := ts.login(t, tt.email, "password")
+
+			form := url.Values{}
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+
+			if tt.wantFlash != "" {
+				_, _, body := ts.get(t, tt.wantLocation)
+				assert.StringContains(t, body, tt.wantFlash)
+			}
+		})
+	}
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/user/login")
+
+		form := url.Values{}
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, _ := ts.postForm(t, "/snippet/1/star", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	t.Run("Star button", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "alice@example.com", "password")
+
+		_, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.StringContains(t, body, "<form action='/snippet/1/star' method='POST'>")
+		assert.StringContains(t, body, "<button>Star</button>")
+	})
+
+	t.Run("Unstar button", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "bob@example.com", "password")
+
+		_, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.StringContains(t, body, "<form action='/snippet/1/unstar' method='POST'>")
+		assert.StringContains(t, body, "<button>Unstar</button>")
+	})
+}
+
+func TestUserStars(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Starred snippets",
+			email:    "bob@example.com",
+			urlPath:  "/user/stars",
+			wantCode: http.StatusOK,
+			wantBody: "An old silent pond",
+		},
+		{
+			name:     "No stars",
+			email:    "alice@example.com",
+			urlPath:  "/user/stars",
+			wantCode: http.StatusOK,
+			wantBody: "You haven't starred any snippets yet.",
+		},
+		{
+			name:     "Out of range page",
+			email:    "bob@example.com",
+			urlPath:  "/user/stars?page=2",
+			wantCode: http.StatusOK,
+			wantBody: "You haven't starred any snippets yet.",
+		},
+		{
+			name:     "Zero page",
+			email:    "bob@example.com",
+			urlPath:  "/user/stars?page=0",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			ts.login(t, tt.email, "password")
+
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, headers, _ := ts.get(t, "/user/stars")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+}
+
+func TestCollectionView(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		urlPath  string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "Public collection",
+			urlPath:  "/collection/onboarding",
+			wantCode: http.StatusOK,
+			wantBody: "Everything a new starter needs.",
+		},
+		{
+			name:     "Snippets in order",
+			urlPath:  "/collection/onboarding",
+			wantCode: http.StatusOK,
+			wantBody: "<a href=\"/snippet/view/6\">Deploying</a>",
+		},
+		{
+			name:     "Unlisted collection by slug",
+			urlPath:  "/collection/dW5saXN0ZWQtY29sbGVjdGlvbg",
+			wantCode: http.StatusOK,
+			wantBody: "<a href=\"/snippet/view/dW5saXN0ZWQtc25pcHBldDM\">Over the wintry forest</a>",
+		},
+		{
+			name:     "Edit link for the owner",
+			email:    "alice@example.com",
+			urlPath:  "/collection/onboarding",
+			wantCode: http.StatusOK,
+			wantBody: "<a href='/collection/edit/onboarding'>Edit collection</a>",
+		},
+		{
+			name:     "Private collection of the owner",
+			email:    "alice@example.com",
+			urlPath:  "/collection/drafts",
+			wantCode: http.StatusOK,
+			wantBody: "There are no snippets in this collection yet.",
+		},
+		{
+			name:     "Private collection of another user",
+			email:    "bob@example.com",
+			urlPath:  "/collection/drafts",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Private collection when logged out",
+			urlPath:  "/collection/drafts",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-existent slug",
+			urlPath:  "/collection/missing",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			if tt.email != "" {
+				ts.login(t, tt.email, "password")
+			}
+
+			code, _, body := ts.get(t, tt.urlPath)
+
+			assert.Equal(t, code, tt.wantCode)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("Listed on the snippet page", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.StringContains(t, body, "In <a href='/collection/onboarding'>Onboarding</a>")
+	})
+}
+
+func TestCollectionCreate(t *testing.T) {
+	app := newTestApplication(t)
+	ts := newTestServer(t, app.routes())
+	defer ts.Close()
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		code, headers, _ := ts.get(t, "/collection/create")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	csrfToken := ts.login(t, "bob@example.com", "password")
+
+	t.Run("Form", func(t *testing.T) {
+		code, _, body := ts.get(t, "/collection/create")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "<form action=\"/collection/create\" method=\"POST\">")
+	})
+
+	tests := []struct {
+		name         string
+		title        string
+		slug         string
+		visibility   string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Valid submission",
+			title:        "Go tips",
+			slug:         "go-tips",
+			visibility:   "public",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/collection/go-tips",
+		},
+		{
+			name:       "Empty title",
+			slug:       "go-tips",
+			visibility: "public",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "This field cannot be blank",
+		},
+		{
+			name:       "Invalid slug",
+			title:      "Go tips",
+			slug:       "Go Tips",
+			visibility: "public",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "This field must be lowercase words of letters and digits separated by hyphens",
+		},
+		{
+			name:       "Reserved slug",
+			title:      "Go tips",
+			slug:       "create",
+			visibility: "public",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "This slug is reserved",
+		},
+		{
+			name:       "Duplicate slug",
+			title:      "Go tips",
+			slug:       "onboarding",
+			visibility: "public",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "This slug is already in use",
+		},
+		{
+			name:       "Invalid visibility",
+			title:      "Go tips",
+			slug:       "go-tips",
+			visibility: "secret",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "This field must equal public, unlisted or private",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			form.Add("title", tt.title)
+			form.Add("slug", tt.slug)
+			form.Add("description", "")
+			form.Add("visibility", tt.visibility)
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, body := ts.postForm(t, "/collection/create", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+
+	t.Run("Unlisted collection gets a random slug", func(t *testing.T) {
+		form := url.Values{}
+		form.Add("title", "Go tips")
+		form.Add("slug", "Go Tips")
+		form.Add("description", "")
+		form.Add("visibility", "unlisted")
+		form.Add("csrf_token", csrfToken)
+
+		code, headers, _ := ts.postForm(t, "/collection/create", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		slug, _ := strings.CutPrefix(headers.Get("Location"), "/collection/")
+		assert.Equal(t, len(slug), 22)
+	})
+}
+
+func TestCollectionEdit(t *testing.T) {
+	app := newTestApplication(t)
+
+	t.Run("Form lists the snippets", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "alice@example.com", "password")
+
+		code, _, body := ts.get(t, "/collection/edit/onboarding")
+
+		assert.Equal(t, code, http.StatusOK)
+		assert.StringContains(t, body, "<input type=\"hidden\" name=\"snippets\" value=\"6\" />")
+	})
+
+	tests := []struct {
+		name         string
+		email        string
+		urlPath      string
+		slug         string
+		visibility   string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Owner",
+			email:        "alice@example.com",
+			urlPath:      "/collection/edit/onboarding",
+			slug:         "onboarding",
+			visibility:   "public",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/collection/onboarding",
+		},
+		{
+			name:         "Owner changes the slug",
+			email:        "alice@example.com",
+			urlPath:      "/collection/edit/onboarding",
+			slug:         "welcome",
+			visibility:   "public",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/collection/welcome",
+		},
+		{
+			name:         "Unlisted collection keeps its slug",
+			email:        "alice@example.com",
+			urlPath:      "/collection/edit/dW5saXN0ZWQtY29sbGVjdGlvbg",
+			slug:         "secrets",
+			visibility:   "unlisted",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/collection/dW5saXN0ZWQtY29sbGVjdGlvbg",
+		},
+		{
+			name:       "Slug of another collection",
+			email:      "alice@example.com",
+			urlPath:    "/collection/edit/onboarding",
+			slug:       "drafts",
+			visibility: "public",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "This slug is already in use",
+		},
+		{
+			name:       "Other user",
+			email:      "bob@example.com",
+			urlPath:    "/collection/edit/onboarding",
+			slug:       "onboarding",
+			visibility: "public",
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "Non-existent slug",
+			email:      "alice@example.com",
+			urlPath:    "/collection/edit/missing",
+			slug:       "missing",
+			visibility: "public",
+			wantCode:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := ts.login(t, tt.email, "password")
+
+			form := url.Values{}
+			form.Add("title", "Onboarding")
+			form.Add("slug", tt.slug)
+			form.Add("description", "")
+			form.Add("visibility", tt.visibility)
+			form.Add("snippets", "6")
+			form.Add("snippets", "1")
+			form.Add("remove", "1")
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, body := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+	t.Run("Collection made unlisted gets a random slug", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		csrfToken := ts.login(t, "alice@example.com", "password")
+
+		form := url.Values{}
+		form.Add("title", "Onboarding")
+		form.Add("slug", "onboarding")
+		form.Add("description", "")
+		form.Add("visibility", "unlisted")
+		form.Add("csrf_token", csrfToken)
+
+		code, headers, _ := ts.postForm(t, "/collection/edit/onboarding", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+
+		slug, _ := strings.CutPrefix(headers.Get("Location"), "/collection/")
+		assert.Equal(t, len(slug), 22)
+	})
+}
+
+func TestSnippetCollect(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name         string
+		email        string
+		urlPath      string
+		collection   string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Own collection",
+			email:        "alice@example.com",
+			urlPath:      "/snippet/collect/1",
+			collection:   "1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1",
+		},
+		{
+			name:         "Unlisted snippet by slug",
+			email:        "alice@example.com",
+			urlPath:      "/snippet/collect/dW5saXN0ZWQtc25pcHBldDM",
+			collection:   "2",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/dW5saXN0ZWQtc25pcHBldDM",
+		},
+		{
+			name:       "Collection of another user",
+			email:      "bob@example.com",
+			urlPath:    "/snippet/collect/1",
+			collection: "1",
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "Unlisted snippet in a public collection",
+			email:      "alice@example.com",
+			urlPath:    "/snippet/collect/dW5saXN0ZWQtc25pcHBldDM",
+			collection: "1",
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:         "Unlisted snippet in an unlisted collection",
+			email:        "alice@example.com",
+			urlPath:      "/snippet/collect/dW5saXN0ZWQtc25pcHBldDM",
+			collection:   "3",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/dW5saXN0ZWQtc25pcHBldDM",
+		},
+		{
+			name:       "Own private snippet",
+			email:      "alice@example.com",
+			urlPath:    "/snippet/collect/4",
+			collection: "1",
+			wantCode:   http.StatusForbidden,
+		},
+		{
+			name:       "Non-existent snippet",
+			email:      "alice@example.com",
+			urlPath:    "/snippet/collect/2",
+			collection: "1",
+			wantCode:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := ts.login(t, tt.email, "password")
+
+			form := url.Values{}
+			form.Add("collection", tt.collection)
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	t.Run("Control on the snippet page", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "alice@example.com", "password")
+
+		_, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.StringContains(t, body, "<form action='/snippet/collect/1' method='POST' class='collect'>")
+		assert.StringContains(t, body, "<option value='2'>Drafts</option>")
+	})
+
+	t.Run("Public collections not offered for unlisted snippets", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "alice@example.com", "password")
+
+		_, _, body := ts.get(t, "/snippet/view/dW5saXN0ZWQtc25pcHBldDM")
+
+		assert.StringContains(t, body, "<option value='3'>Secrets</option>")
+		assert.Equal(t, strings.Contains(body, "<option value='1'>Onboarding</option>"), false)
+	})
+}
+
+func TestUserCollections(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		wantBody string
+	}{
+		{
+			name:     "With collections",
+			email:    "alice@example.com",
+			wantBody: "<a href='/collection/drafts'>Drafts</a>",
+		},
+		{
+			name:     "Without collections",
+			email:    "bob@example.com",
+			wantBody: "You haven't created any collections yet.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			ts.login(t, tt.email, "password")
+
+			code, _, body := ts.get(t, "/user/collections")
+
+			assert.Equal(t, code, http.StatusOK)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestUserTokens(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name     string
+		email    string
+		wantBody string
+	}{
+		{
+			name:     "With tokens",
+			email:    "alice@example.com",
+			wantBody: "<td>Deploy script</td>",
+		},
+		{
+			name:     "Revoke button",
+			email:    "alice@example.com",
+			wantBody: "<form action='/token/delete/1' method='POST'>",
+		},
+		{
+			name:     "Without tokens",
+			email:    "bob@example.com",
+			wantBody: "You haven't created any API tokens yet.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			ts.login(t, tt.email, "password")
+
+			code, _, body := ts.get(t, "/user/tokens")
+
+			assert.Equal(t, code, http.StatusOK)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		code, headers, _ := ts.get(t, "/user/tokens")
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+}
+
+func TestTokenCreate(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name      string
+		tokenName string
+		expires   string
+		wantCode  int
+		wantBody  string
+	}{
+		{
+			name:      "Valid submission",
+			tokenName: "CI",
+			expires:   "30",
+			wantCode:  http.StatusOK,
+			wantBody:  "<input type='text' value='sbx_bmV3LXRva2Vu' readonly />",
+		},
+		{
+			name:      "Never expires",
+			tokenName: "CI",
+			expires:   "never",
+			wantCode:  http.StatusOK,
+			wantBody:  "Copy it now, as it won't be shown again.",
+		},
+		{
+			name:      "Blank name",
+			tokenName: "",
+			expires:   "30",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field cannot be blank",
+		},
+		{
+			name:      "Invalid expires",
+			tokenName: "CI",
+			expires:   "7",
+			wantCode:  http.StatusUnprocessableEntity,
+			wantBody:  "This field must equal 30, 90, 365 or never",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := ts.login(t, "alice@example.com", "password")
+
+			form := url.Values{}
+			form.Add("name", tt.tokenName)
+			form.Add("expires", tt.expires)
+			form.Add("csrf_token", csrfToken)
+
+			code, _, body := ts.postForm(t, "/user/tokens", form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, body, tt.wantBody)
+		})
+	}
+}
+
+func TestTokenDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name         string
+		email        string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Own token",
+			email:        "alice@example.com",
+			urlPath:      "/token/delete/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/user/tokens",
+		},
+		{
+			name:     "Token of another user",
+			email:    "bob@example.com",
+			urlPath:  "/token/delete/1",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Non-existent ID",
+			email:    "alice@example.com",
+			urlPath:  "/token/delete/2",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Invalid ID",
+			email:    "alice@example.com",
+			urlPath:  "/token/delete/foo",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := ts.login(t, tt.email, "password")
+
+			form := url.Values{}
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+}
+
+func TestCommentCreate(t *testing.T) {
+	app := newTestApplication(t)
+
+	t.Run("Comments on the view page", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.StringContains(t, body, "What a lovely haiku.")
+		assert.StringContains(t, body, "to leave a comment.")
+		assert.StringContains(t, body, "On line 1 of pond.txt in revision 1")
+		assert.Equal(t, strings.Contains(body, "<td class='add-comment'>"), false)
+	})
+
+	t.Run("Line comments next to their line", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/snippet/view/1")
+
+		line := strings.Index(body, "<tr id='frog.txt-L2'>")
+		comment := strings.Index(body, "Is the splash too loud?")
+		end := strings.Index(body[line:], "</table>")
+
+		if line < 0 || comment < line || comment > line+end {
+			t.Errorf("want the line comment inside the row of line 2 of frog.txt")
+		}
+		assert.Equal(t, strings.Contains(body, "<form action='/comment/delete/1'"), false)
+	})
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		_, _, body := ts.get(t, "/user/login")
+
+		form := url.Values{}
+		form.Add("content", "Nice!")
+		form.Add("csrf_token", extractCSRFToken(t, body))
+
+		code, headers, _ := ts.postForm(t, "/snippet/comment/1", form)
+
+		assert.Equal(t, code, http.StatusSeeOther)
+		assert.Equal(t, headers.Get("Location"), "/user/login")
+	})
+
+	t.Run("Missing CSRF token", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "bob@example.com", "password")
+
+		form := url.Values{}
+		form.Add("content", "Nice!")
+
+		code, _, _ := ts.postForm(t, "/snippet/comment/1", form)
+
+		assert.Equal(t, code, http.StatusBadRequest)
+	})
+
+	tests := []struct {
+		name         string
+		urlPath      string
+		file         string
+		line         string
+		content      string
+		wantCode     int
+		wantLocation string
+		wantBody     string
+	}{
+		{
+			name:         "Valid comment",
+			urlPath:      "/snippet/comment/1",
+			content:      "Nice!",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comment-2",
+		},
+		{
+			name:         "Unlisted snippet by slug",
+			urlPath:      "/snippet/comment/dW5saXN0ZWQtc25pcHBldDM",
+			content:      "Nice!",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/dW5saXN0ZWQtc25pcHBldDM#comment-2",
+		},
+		{
+			name:         "Valid line comment",
+			urlPath:      "/snippet/comment/1",
+			file:         "frog.txt",
+			line:         "2",
+			content:      "Nice!",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comment-2",
+		},
+		{
+			name:     "Line of an unknown file",
+			urlPath:  "/snippet/comment/1",
+			file:     "toad.txt",
+			line:     "1",
+			content:  "Nice!",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field must be one of the files of the snippet",
+		},
+		{
+			name:     "Line without a file",
+			urlPath:  "/snippet/comment/1",
+			line:     "1",
+			content:  "Nice!",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field must be one of the files of the snippet",
+		},
+		{
+			name:     "Line past the end of the file",
+			urlPath:  "/snippet/comment/1",
+			file:     "frog.txt",
+			line:     "3",
+			content:  "Nice!",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field must be a line number between 1 and 2",
+		},
+		{
+			name:     "File without a line",
+			urlPath:  "/snippet/comment/1",
+			file:     "frog.txt",
+			content:  "Nice!",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field must be a line number between 1 and 2",
+		},
+		{
+			name:     "Non-numeric line",
+			urlPath:  "/snippet/comment/1",
+			file:     "frog.txt",
+			line:     "two",
+			content:  "Nice!",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Empty comment",
+			urlPath:  "/snippet/comment/1",
+			content:  " ",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be blank",
+		},
+		{
+			name:     "Too long",
+			urlPath:  "/snippet/comment/1",
+			content:  strings.Repeat("a", 2001),
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "This field cannot be more than 2000 characters long",
+		},
+		{
+			name:     "Private snippet",
+			urlPath:  "/snippet/comment/4",
+			content:  "Nice!",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Burn after reading snippet",
+			urlPath:  "/snippet/comment/YnVybi1zbmlwcGV0LWZpdmU",
+			content:  "Nice!",
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := ts.login(t, "bob@example.com", "password")
+
+			form := url.Values{}
+			form.Add("file", tt.file)
+			form.Add("line", tt.line)
+			form.Add("content", tt.content)
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, body := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestCommentDelete(t *testing.T) {
+	app := newTestApplication(t)
+
+	tests := []struct {
+		name         string
+		email        string
+		urlPath      string
+		wantCode     int
+		wantLocation string
+	}{
+		{
+			name:         "Comment author",
+			email:        "bob@example.com",
+			urlPath:      "/comment/delete/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comments",
+		},
+		{
+			name:         "Snippet owner",
+			email:        "alice@example.com",
+			urlPath:      "/comment/delete/1",
+			wantCode:     http.StatusSeeOther,
+			wantLocation: "/snippet/view/1#comments",
+		},
+		{
+			name:     "Someone else",
+			email:    "carol@example.com",
+			urlPath:  "/comment/delete/1",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "Non-existent ID",
+			email:    "bob@example.com",
+			urlPath:  "/comment/delete/2",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			csrfToken := ts.login(t, tt.email, "password")
+
+			form := url.Values{}
+			form.Add("csrf_token", csrfToken)
+
+			code, headers, _ := ts.postForm(t, tt.urlPath, form)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.Equal(t, headers.Get("Location"), tt.wantLocation)
+		})
+	}
+
+	t.Run("Delete button", func(t *testing.T) {
+		ts := newTestServer(t, app.routes())
+		defer ts.Close()
+
+		ts.login(t, "alice@example.com", "password")
+
+		_, _, body := ts.get(t, "/snippet/view/1")
+
+		assert.StringContains(t, body, "<form action='/comment/delete/1' method='POST'>")
+	})
+}
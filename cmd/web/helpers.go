@@ -2,12 +2,21 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"regexp"
 	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Overlrd/snippetbox/internal/models"
+	"github.com/Overlrd/snippetbox/internal/validator"
 	"github.com/go-playground/form/v4"
 	"github.com/justinas/nosurf"
 )
@@ -66,10 +75,11 @@ func (app *application) render(w http.ResponseWriter, r *http.Request, status in
 // struct initialized with the current year.
 func (app *application) newTemplateData(r *http.Request) templateData {
 	return templateData{
-		CurrentYear:     time.Now().Year(),
-		Flash:           app.sessionManager.PopString(r.Context(), "flash"),
-		IsAuthenticated: app.isAuthenticated(r),
-		CSRFToken:       nosurf.Token(r),
+		CurrentYear:         time.Now().Year(),
+		Flash:               app.sessionManager.PopString(r.Context(), "flash"),
+		IsAuthenticated:     app.isAuthenticated(r),
+		AuthenticatedUserID: app.authenticatedUserID(r),
+		CSRFToken:           nosurf.Token(r),
 	}
 }
 
@@ -108,3 +118,389 @@ func (app *application) isAuthenticated(r *http.Request) bool {
 
 	return isAuthenticated
 }
+
+// Return the ID of the current user if the request is authenticated,
+// otherwise return 0.
+func (app *application) authenticatedUserID(r *http.Request) int {
+	if !app.isAuthenticated(r) {
+		return 0
+	}
+
+	// API requests carry the user ID in the request context instead of the
+	// session.
+	if id, ok := r.Context().Value(AuthenticatedUserIDContextKey).(int); ok {
+		return id
+	}
+
+	return app.sessionManager.GetInt(r.Context(), "authenticatedUserID")
+}
+
+// Return the snippet which was stored in the request context by the
+// requireSnippetOwner or requireSnippetOwnerOrAdmin middleware.
+func (app *application) contextSnippet(r *http.Request) models.Snippet {
+	snippet, ok := r.Context().Value(SnippetContextKey).(models.Snippet)
+	if !ok {
+		panic("no snippet in request context")
+	}
+
+	return snippet
+}
+
+// Return true if the snippet belongs to the current user. Anonymous
+// snippets belong to nobody.
+func (app *application) isSnippetOwner(r *http.Request, snippet models.Snippet) bool {
+	return snippet.UserID != 0 && snippet.UserID == app.authenticatedUserID(r)
+}
+
+// The viewableSnippet() helper fetches the snippet identified by the "id"
+// wildcard, which is either its numeric ID or its slug, and checks that the
+// current user is allowed to see it. Snippets which the user isn't allowed
+// to see are reported as models.ErrNoRecord, so that their existence isn't
+// revealed.
+func (app *application) viewableSnippet(r *http.Request) (models.Snippet, error) {
+	var (
+		snippet models.Snippet
+		err     error
+		bySlug  bool
+	)
+
+	if id, convErr := strconv.Atoi(r.PathValue("id")); convErr == nil {
+		if id < 1 {
+			return models.Snippet{}, models.ErrNoRecord
+		}
+		snippet, err = app.snippets.Get(id)
+	} else {
+		snippet, err = app.snippets.GetBySlug(r.PathValue("id"))
+		bySlug = true
+	}
+	if err != nil {
+		return models.Snippet{}, err
+	}
+
+	isOwner := app.isSnippetOwner(r, snippet)
+
+	// Public snippets can be seen by anyone, unlisted snippets by anyone who
+	// knows their slug and private snippets by their owner only.
+	switch snippet.Visibility {
+	case models.VisibilityPublic:
+	case models.VisibilityUnlisted:
+		if !bySlug && !isOwner {
+			return models.Snippet{}, models.ErrNoRecord
+		}
+	default:
+		if !isOwner {
+			return models.Snippet{}, models.ErrNoRecord
+		}
+	}
+
+	return snippet, nil
+}
+
+// The maximum time for which shared caches may keep raw public snippets.
+const rawCacheMaxAge = 5 * time.Minute
+
+// The readableSnippet() helper fetches the snippet for the pages which show
+// its content outside of the snippet view page, such as the raw endpoint or
+// its history, applying the same visibility rules. If the snippet can't be
+// shown it sends the error response and returns false.
+func (app *application) readableSnippet(w http.ResponseWriter, r *http.Request) (models.Snippet, bool) {
+	snippet, err := app.viewableSnippet(r)
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return models.Snippet{}, false
+	}
+
+	// Burn-after-reading snippets can only be revealed through the warning
+	// page, otherwise fetching the raw content would bypass it.
+	if snippet.BurnAfterReading && !app.isSnippetOwner(r, snippet) {
+		app.clientError(w, http.StatusForbidden)
+		return models.Snippet{}, false
+	}
+
+	return snippet, true
+}
+
+// The rawSnippet() helper fetches the snippet for the raw and download
+// endpoints like readableSnippet() and sets the caching headers of the
+// response.
+func (app *application) rawSnippet(w http.ResponseWriter, r *http.Request) (models.Snippet, bool) {
+	snippet, ok := app.readableSnippet(w, r)
+	if !ok {
+		return models.Snippet{}, false
+	}
+
+	// Public snippets may be cached for a few minutes, but never beyond their
+	// expiry, if they have one. Anything else must not be stored by shared
+	// caches.
+	if snippet.Visibility == models.VisibilityPublic {
+		maxAge := rawCacheMaxAge
+		if !snippet.Expires.IsZero() {
+			maxAge = min(maxAge, time.Until(snippet.Expires))
+		}
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", max(int(maxAge.Seconds()), 0)))
+	} else {
+		w.Header().Set("Cache-Control", "private, no-store")
+	}
+
+	return snippet, true
+}
+
+// The nonSlugChars regular expression matches runs of characters which are
+// replaced with a dash when turning a title into a file name.
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// The snippetArchiveName() helper derives the name of the zip archive holding
+// the files of a snippet from its title, such as "an-old-silent-pond.zip".
+func snippetArchiveName(snippet models.Snippet) string {
+	name := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(snippet.Title), "-"), "-")
+	if name == "" {
+		name = fmt.Sprintf("snippet-%d", snippet.ID)
+	}
+
+	return name + ".zip"
+}
+
+// The snippetFile() helper returns the file of a snippet named by the "file"
+// path value, or the first file if there is no such value. It returns false
+// if the snippet has no such file.
+func snippetFile(r *http.Request, snippet models.Snippet) (models.File, bool) {
+	name := r.PathValue("file")
+
+	for _, file := range snippet.Files {
+		if name == "" || file.Name == name {
+			return file, true
+		}
+	}
+
+	return models.File{}, false
+}
+
+// The readRevision() helper reads a revision number from the given query
+// string parameter, falling back to def if it is missing. It returns false
+// if the value is not a positive integer.
+func readRevision(r *http.Request, key string, def int) (int, bool) {
+	s := r.URL.Query().Get(key)
+	if s == "" {
+		return def, true
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// The subpages() helper returns a handler which passes requests on to the
+// handler for their "page" wildcard, and sends a 404 Not Found response for
+// unknown pages.
+func subpages(handlers map[string]http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, ok := handlers[r.PathValue("page")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		handler(w, r)
+	})
+}
+
+// The redirectToSnippet() helper redirects to the page of a snippet which
+// has just been saved. The snippet is fetched again for its slug, which is
+// part of the URL of unlisted snippets and changes when a snippet is made
+// unlisted, so that the owner isn't left with a URL nobody else can open.
+func (app *application) redirectToSnippet(w http.ResponseWriter, r *http.Request, id int) {
+	snippet, err := app.snippets.Get(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, snippetURL(snippet), http.StatusSeeOther)
+}
+
+// The ownCollection() helper fetches the collection identified by the
+// "slug" wildcard, and checks that it belongs to the current user. If it
+// doesn't, an error response is sent and ok is false.
+func (app *application) ownCollection(w http.ResponseWriter, r *http.Request) (models.Collection, bool) {
+	collection, err := app.collections.Get(r.PathValue("slug"))
+	if err != nil {
+		if errors.Is(err, models.ErrNoRecord) {
+			http.NotFound(w, r)
+		} else {
+			app.serverError(w, r, err)
+		}
+		return models.Collection{}, false
+	}
+
+	if collection.UserID != app.authenticatedUserID(r) {
+		app.clientError(w, http.StatusForbidden)
+		return models.Collection{}, false
+	}
+
+	return collection, true
+}
+
+// The canCollect() helper reports whether a snippet may be added to a
+// collection. Unlisted snippets can't be added to public collections, which
+// would give away their slugs.
+func canCollect(c models.Collection, s models.Snippet) bool {
+	return s.Visibility != models.VisibilityUnlisted || c.Visibility != models.VisibilityPublic
+}
+
+// The orderSnippets() helper returns the snippets whose IDs are listed, in
+// the order of the list.
+func orderSnippets(snippets []models.Snippet, ids []int) []models.Snippet {
+	var ordered []models.Snippet
+
+	for _, id := range ids {
+		i := slices.IndexFunc(snippets, func(s models.Snippet) bool {
+			return s.ID == id
+		})
+		if i >= 0 {
+			ordered = append(ordered, snippets[i])
+		}
+	}
+
+	return ordered
+}
+
+// Define an envelope type for the JSON responses of the API. Every response
+// is a JSON object, with the data under a descriptive key such as "snippet",
+// or an "error" key describing what went wrong.
+type envelope map[string]any
+
+// The writeJSON() helper sends data encoded as JSON with the given status
+// code.
+func (app *application) writeJSON(w http.ResponseWriter, status int, data envelope) error {
+	js, err := json.MarshalIndent(data, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	js = append(js, '\n')
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(js)
+
+	return nil
+}
+
+// The maximum size of the JSON body of an API request.
+const maxJSONBytes = 1 << 20
+
+// The readJSON() helper decodes the JSON body of a request into dst. The
+// body must be labelled as JSON, contain a single JSON value whose fields
+// are all known, and be no larger than maxJSONBytes. The errors it returns
+// are meant for clients.
+func (app *application) readJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return errors.New("body must have the Content-Type application/json")
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxJSONBytes)
+
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+
+	err := dec.Decode(dst)
+	if err != nil {
+		var syntaxError *json.SyntaxError
+		var unmarshalTypeError *json.UnmarshalTypeError
+		var maxBytesError *http.MaxBytesError
+		var invalidUnmarshalError *json.InvalidUnmarshalError
+
+		switch {
+		case errors.As(err, &syntaxError):
+			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+		case errors.Is(err, io.ErrUnexpectedEOF):
+			return errors.New("body contains badly-formed JSON")
+		case errors.As(err, &unmarshalTypeError):
+			if unmarshalTypeError.Field != "" {
+				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
+			}
+			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+		case errors.Is(err, io.EOF):
+			return errors.New("body must not be empty")
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fmt.Errorf("body contains unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+		case errors.As(err, &maxBytesError):
+			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+		case errors.As(err, &invalidUnmarshalError):
+			// Like decodePostForm(), panic on an invalid destination, as
+			// that is a bug in the application.
+			panic(err)
+		default:
+			return err
+		}
+	}
+
+	if dec.More() {
+		return errors.New("body must only contain a single JSON value")
+	}
+
+	return nil
+}
+
+// The errorJSON() helper sends an error response in the JSON envelope used by
+// the API, with the given message.
+func (app *application) errorJSON(w http.ResponseWriter, r *http.Request, status int, message string) {
+	err := app.writeJSON(w, status, envelope{"error": message})
+	if err != nil {
+		app.logger.Error(err.Error(), "method", r.Method, "url", r.URL.RequestURI())
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// The serverErrorJSON helper logs an error like serverError(), and sends a
+// generic 500 Internal Server Error response in JSON.
+func (app *application) serverErrorJSON(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		method = r.Method
+		url    = r.URL.RequestURI()
+		trace  = string(debug.Stack())
+	)
+
+	app.logger.Error(err.Error(), "method", method, "url", url, "trace", trace)
+	app.errorJSON(w, r, http.StatusInternalServerError, "the server encountered a problem and could not process your request")
+}
+
+// The clientErrorJSON helper sends a JSON error response with the standard
+// description of the status code as the message, like clientError().
+func (app *application) clientErrorJSON(w http.ResponseWriter, r *http.Request, status int) {
+	app.errorJSON(w, r, status, strings.ToLower(http.StatusText(status)))
+}
+
+// The failedValidationJSON helper sends a 422 Unprocessable Entity response
+// listing the errors found by a validator, with the field errors under
+// "fields" and any other errors under "errors".
+func (app *application) failedValidationJSON(w http.ResponseWriter, r *http.Request, v validator.Validator) {
+	data := envelope{
+		"error":  "the request contains invalid data",
+		"fields": v.FieldErrors,
+	}
+
+	if len(v.NonFieldErrors) > 0 {
+		data["errors"] = v.NonFieldErrors
+	}
+
+	err := app.writeJSON(w, http.StatusUnprocessableEntity, data)
+	if err != nil {
+		app.serverErrorJSON(w, r, err)
+	}
+}
+
+// The invalidCredentialsJSON helper sends a 401 Unauthorized response for API
+// requests with a missing, expired or malformed API token.
+func (app *application) invalidCredentialsJSON(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+	app.errorJSON(w, r, http.StatusUnauthorized, "invalid or expired authentication token")
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"database/sql"
+	"errors"
 	"flag"
+	"fmt"
 	"html/template"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	// Import the models package prefixed with the application module path
@@ -25,17 +33,49 @@ type application struct {
 	logger         *slog.Logger
 	snippets       models.SnippetModelInterface
 	users          models.UserModelInterface
+	tags           models.TagModelInterface
+	revisions      models.RevisionModelInterface
+	comments       models.CommentModelInterface
+	stars          models.StarModelInterface
+	collections    models.CollectionModelInterface
+	tokens         models.TokenModelInterface
+	views          models.ViewModelInterface
+	viewCounter    *viewCounter
 	templateCache  map[string]*template.Template
 	formDecoder    *form.Decoder
 	sessionManager *scs.SessionManager
+	maxExpiry      time.Duration
+	anonPastes     bool
+	baseURL        string
 }
 
 func main() {
 	// Define command line flags
 	addr := flag.String("addr", ":4000", "HTTP network address")
 	dsn := flag.String("dsn", "web:pass@/snippetbox?parseTime=true", "MySQL data source name")
+	maxExpiry := flag.Duration("max-expiry", 5*365*24*time.Hour, "Latest custom expiry date of a snippet, from now")
+	reapInterval := flag.Duration("reap-interval", 10*time.Minute, "How often expired snippets are deleted (0 to disable)")
+	reapBatchSize := flag.Int("reap-batch-size", 500, "Maximum number of expired snippets deleted per query")
+	viewFlushInterval := flag.Duration("view-flush-interval", time.Minute, "How often view counts are written to the database")
+	anonPastes := flag.Bool("anonymous-pastes", false, "Allow pasting snippets to POST / without an API token")
+	baseURL := flag.String("base-url", "https://localhost:4000", "Public URL of the server, used in the responses to pastes")
 	flag.Parse()
 
+	if *reapBatchSize < 1 {
+		fmt.Fprintln(os.Stderr, "-reap-batch-size must be at least 1")
+		os.Exit(2)
+	}
+
+	if *viewFlushInterval <= 0 {
+		fmt.Fprintln(os.Stderr, "-view-flush-interval must be positive")
+		os.Exit(2)
+	}
+
+	if u, err := url.Parse(*baseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		fmt.Fprintln(os.Stderr, "-base-url must be an absolute URL, such as https://snippetbox.example.com")
+		os.Exit(2)
+	}
+
 	// Initialize a new logger
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
 		Level:     slog.LevelDebug,
@@ -67,15 +107,29 @@ func main() {
 	SessionManager := scs.New()
 	SessionManager.Store = mysqlstore.New(db)
 	SessionManager.Lifetime = 12 * time.Hour
+	// Views are counted in memory and written to the database periodically
+	views := &models.ViewModel{DB: db}
+
 	// Initialize a new instance of the application struct, containing the
 	// dependencies
 	app := &application{
 		logger:         logger,
 		snippets:       &models.SnippetModel{DB: db},
 		users:          &models.UserModel{DB: db},
+		tags:           &models.TagModel{DB: db},
+		revisions:      &models.RevisionModel{DB: db},
+		comments:       &models.CommentModel{DB: db},
+		stars:          &models.StarModel{DB: db},
+		collections:    &models.CollectionModel{DB: db},
+		tokens:         &models.TokenModel{DB: db},
+		views:          views,
+		viewCounter:    newViewCounter(views),
 		templateCache:  templateCache,
 		formDecoder:    formDecoder,
 		sessionManager: SessionManager,
+		maxExpiry:      *maxExpiry,
+		anonPastes:     *anonPastes,
+		baseURL:        strings.TrimSuffix(*baseURL, "/"),
 	}
 
 	// Initialize a tls.Config struct to hold non-default TLS settings we
@@ -96,12 +150,64 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	// Create a context which is cancelled when the process is asked to
+	// stop, and use it to shut down the server and background goroutines.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start the reaper which deletes expired snippets in the background.
+	var wg sync.WaitGroup
+
+	if *reapInterval > 0 {
+		wg.Go(func() {
+			app.reapExpiredSnippets(ctx, *reapInterval, *reapBatchSize)
+		})
+	}
+
+	// And the one which writes view counts to the database.
+	wg.Go(func() {
+		app.flushViews(ctx, *viewFlushInterval)
+	})
+
+	shutdownErr := make(chan error)
+	go func() {
+		<-ctx.Done()
+
+		logger.Info("shutting down server")
+
+		// Give in-flight requests a few seconds to complete.
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		shutdownErr <- srv.Shutdown(shutdownCtx)
+	}()
+
 	logger.Info("starting server", "addr", *addr)
 
-	// ListenAndServeTLS() to start the HTTPS server
+	// ListenAndServeTLS() to start the HTTPS server. It returns
+	// http.ErrServerClosed as soon as Shutdown() is called.
 	err = srv.ListenAndServeTLS("./tls/cert.pem", "./tls/key.pem")
-	logger.Error(err.Error())
-	os.Exit(1)
+	if !errors.Is(err, http.ErrServerClosed) {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+
+	// Wait for in-flight requests, and for the reaper to finish any batch in
+	// progress, before the database connection pool is closed.
+	err = <-shutdownErr
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	wg.Wait()
+
+	// Write the views counted since the last flush.
+	err = app.viewCounter.flush()
+	if err != nil {
+		logger.Error("flushing snippet views", "error", err.Error())
+	}
+
+	logger.Info("stopped server")
 }
 
 // The openDB functon wraps sql.Open() and returns a sql.DB connection pool
@@ -2,9 +2,13 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/Overlrd/snippetbox/internal/models"
 	"github.com/justinas/nosurf"
 )
 
@@ -73,6 +77,67 @@ func (app *application) requireAuthentication(next http.Handler) http.Handler {
 	})
 }
 
+// The requireSnippetOwner middleware loads the snippet identified by the "id"
+// wildcard and only lets the request through if it belongs to the current
+// user. It must be used after requireAuthentication.
+func (app *application) requireSnippetOwner(next http.Handler) http.Handler {
+	return app.authorizeSnippet(next, false)
+}
+
+// The requireSnippetOwnerOrAdmin middleware works like requireSnippetOwner,
+// but also lets administrators through.
+func (app *application) requireSnippetOwnerOrAdmin(next http.Handler) http.Handler {
+	return app.authorizeSnippet(next, true)
+}
+
+func (app *application) authorizeSnippet(next http.Handler, allowAdmin bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(r.PathValue("id"))
+		if err != nil || id < 1 {
+			http.NotFound(w, r)
+			return
+		}
+
+		snippet, err := app.snippets.Get(id)
+		if err != nil {
+			if errors.Is(err, models.ErrNoRecord) {
+				http.NotFound(w, r)
+			} else {
+				app.serverError(w, r, err)
+			}
+			return
+		}
+
+		userID := app.authenticatedUserID(r)
+
+		if snippet.UserID != userID {
+			// Send a 404 Not Found response if the snippet belongs to someone
+			// else, unless admins are allowed and the current user is one. A
+			// 403 would confirm that private and unlisted snippets exist.
+			if !allowAdmin {
+				http.NotFound(w, r)
+				return
+			}
+
+			user, err := app.users.Get(userID)
+			if err != nil {
+				app.serverError(w, r, err)
+				return
+			}
+
+			if !user.IsAdmin {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		// Store the snippet in the request context so that the next handler
+		// can use it without querying the database again.
+		ctx := context.WithValue(r.Context(), SnippetContextKey, snippet)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
 // Create a NoSurf middleware function which uses a customized CSRF cookie with
 // the Secure, Path and HttpOnly attributes set.
 func noSurf(next http.Handler) http.Handler {
@@ -118,3 +183,60 @@ func (app *application) authenticate(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// The authenticateToken middleware authenticates API requests which carry a
+// personal API token in an "Authorization: Bearer <token>" header, populating
+// the request context like the authenticate middleware does for sessions.
+// Requests without an Authorization header are let through anonymously, and
+// those with a malformed header or an unknown or expired token are rejected.
+func (app *application) authenticateToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Let caches know that the response depends on the header.
+		w.Header().Add("Vary", "Authorization")
+
+		header := r.Header.Get("Authorization")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The authentication scheme is case-insensitive.
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			app.invalidCredentialsJSON(w, r)
+			return
+		}
+
+		id, err := app.tokens.Authenticate(token)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidCredentials) {
+				app.invalidCredentialsJSON(w, r)
+			} else {
+				app.serverErrorJSON(w, r, err)
+			}
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), IsAuthenticatedContextKey, true)
+		ctx = context.WithValue(ctx, AuthenticatedUserIDContextKey, id)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// The requireAPIAuthentication middleware works like requireAuthentication
+// for the API, sending a 401 Unauthorized response instead of redirecting to
+// the login page.
+func (app *application) requireAPIAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAuthenticated(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			app.errorJSON(w, r, http.StatusUnauthorized, "you must be authenticated to access this resource")
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+
+		next.ServeHTTP(w, r)
+	})
+}
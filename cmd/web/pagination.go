@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// The number of snippets shown on each page of a listing.
+const snippetsPerPage = 10
+
+// The pagination type holds the data needed by the "pagination" partial to
+// render the previous and next links of a paginated listing.
+type pagination struct {
+	Page         int
+	PageSize     int
+	TotalRecords int
+	url          url.URL
+}
+
+// Create a newPagination() helper which keeps a copy of the request URL, so
+// that the links to other pages preserve the path and any other query
+// string parameters.
+func newPagination(r *http.Request, page, pageSize, totalRecords int) pagination {
+	return pagination{
+		Page:         page,
+		PageSize:     pageSize,
+		TotalRecords: totalRecords,
+		url:          *r.URL,
+	}
+}
+
+// LastPage() returns the number of the last page, which is at least 1.
+func (p pagination) LastPage() int {
+	if p.TotalRecords == 0 || p.PageSize < 1 {
+		return 1
+	}
+
+	return (p.TotalRecords + p.PageSize - 1) / p.PageSize
+}
+
+func (p pagination) HasPrevious() bool {
+	return p.Page > 1
+}
+
+func (p pagination) HasNext() bool {
+	return p.Page < p.LastPage()
+}
+
+func (p pagination) PreviousURL() string {
+	return p.pageURL(p.Page - 1)
+}
+
+func (p pagination) NextURL() string {
+	return p.pageURL(p.Page + 1)
+}
+
+// pageURL() returns the request URL with the page query string parameter
+// set to the given page.
+func (p pagination) pageURL(page int) string {
+	u := p.url
+
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page))
+	u.RawQuery = query.Encode()
+
+	return u.RequestURI()
+}
+
+// The readPage() helper reads the page number from the query string,
+// defaulting to the first page. It returns false if the value is not a
+// positive integer.
+func readPage(r *http.Request) (int, bool) {
+	s := r.URL.Query().Get("page")
+	if s == "" {
+		return 1, true
+	}
+
+	page, err := strconv.Atoi(s)
+	if err != nil || page < 1 {
+		return 0, false
+	}
+
+	return page, true
+}
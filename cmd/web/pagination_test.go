@@ -0,0 +1,71 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+)
+
+func TestPagination(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		page         int
+		totalRecords int
+		wantLastPage int
+		wantPrevious string
+		wantNext     string
+	}{
+		{
+			name:         "No records",
+			target:       "/",
+			page:         1,
+			totalRecords: 0,
+			wantLastPage: 1,
+		},
+		{
+			name:         "First page",
+			target:       "/",
+			page:         1,
+			totalRecords: 25,
+			wantLastPage: 3,
+			wantNext:     "/?page=2",
+		},
+		{
+			name:         "Middle page keeps query",
+			target:       "/search?q=pond&page=2",
+			page:         2,
+			totalRecords: 25,
+			wantLastPage: 3,
+			wantPrevious: "/search?page=1&q=pond",
+			wantNext:     "/search?page=3&q=pond",
+		},
+		{
+			name:         "Last page",
+			target:       "/?page=3",
+			page:         3,
+			totalRecords: 30,
+			wantLastPage: 3,
+			wantPrevious: "/?page=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.target, nil)
+			p := newPagination(r, tt.page, 10, tt.totalRecords)
+
+			assert.Equal(t, p.LastPage(), tt.wantLastPage)
+			assert.Equal(t, p.HasPrevious(), tt.wantPrevious != "")
+			assert.Equal(t, p.HasNext(), tt.wantNext != "")
+
+			if p.HasPrevious() {
+				assert.Equal(t, p.PreviousURL(), tt.wantPrevious)
+			}
+			if p.HasNext() {
+				assert.Equal(t, p.NextURL(), tt.wantNext)
+			}
+		})
+	}
+}
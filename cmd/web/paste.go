@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Overlrd/snippetbox/internal/models"
+	"github.com/Overlrd/snippetbox/internal/syntax"
+	"github.com/Overlrd/snippetbox/internal/validator"
+)
+
+// The maximum size of the body of a paste, including the encoding of
+// multipart uploads.
+const maxPasteBytes = 1 << 20
+
+// postPaste: Create a snippet from the body of the request and send back its
+// URL as plain text, so that snippets can be pasted from a terminal:
+//
+//	curl --data-binary @main.go 'https://snippetbox.example.com/?expires=7'
+//	make 2>&1 | curl -F 'f=@-' https://snippetbox.example.com/
+//
+// A raw body makes a single file, named by the "name" query string
+// parameter, while each file of a multipart upload makes a file named after
+// the uploaded file. The title, visibility, tags, expires and expires_at
+// parameters work like the fields of the create snippet form, and language
+// overrides the language guessed from the file names. Requests without an
+// API token create anonymous snippets, if the server allows it.
+func (app *application) postPaste(w http.ResponseWriter, r *http.Request) {
+	userID := app.authenticatedUserID(r)
+
+	// A missing token gets the same kind of JSON response as an invalid one,
+	// which is sent by authenticateToken.
+	if userID == 0 && !app.anonPastes {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		app.errorJSON(w, r, http.StatusUnauthorized, "you must paste with an API token, which you can create at /user/tokens")
+		return
+	}
+
+	files, err := readPasteFiles(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+
+	form := snippetCreateForm{
+		Title:      query.Get("title"),
+		Files:      files,
+		Visibility: query.Get("visibility"),
+		Tags:       query.Get("tags"),
+		Expires:    query.Get("expires"),
+		ExpiresAt:  query.Get("expires_at"),
+	}
+
+	// Use the same defaults as the API.
+	if form.Visibility == "" {
+		form.Visibility = models.VisibilityPublic
+	}
+	if form.Expires == "" {
+		form.Expires = "365"
+	}
+
+	for i, f := range form.Files {
+		switch {
+		case query.Get("language") != "":
+			form.Files[i].Language = query.Get("language")
+		case f.Name != "":
+			form.Files[i].Language = syntax.ForFile(f.Name)
+		default:
+			form.Files[i].Language = syntax.PlainText
+		}
+	}
+
+	if form.Title == "" {
+		form.Title = "Untitled paste"
+		if len(form.Files) > 0 && form.Files[0].Name != "" {
+			form.Title = form.Files[0].Name
+		}
+	}
+
+	form.validate(app.maxExpiry)
+
+	// Nobody could ever see a private snippet without an owner.
+	if userID == 0 {
+		form.CheckField(form.Visibility != models.VisibilityPrivate, "visibility", "Anonymous snippets cannot be private")
+	}
+
+	if !form.Valid() {
+		failedValidationText(w, form.Validator)
+		return
+	}
+
+	expires, burn := form.expiry()
+
+	id, err := app.snippets.Insert(userID, form.Title, form.files(), form.Visibility, expires, burn)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	err = app.tags.Set(id, form.tagNames())
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Fetch the new snippet for its slug, which is part of the URL of
+	// unlisted snippets.
+	snippet, err := app.snippets.Get(id)
+	if err != nil {
+		app.serverError(w, r, err)
+		return
+	}
+
+	// Build the URL from the configured base URL rather than the Host
+	// header, which is chosen by the client.
+	url := app.baseURL + snippetURL(snippet)
+
+	w.Header().Set("Location", url)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintln(w, url)
+}
+
+// The readPasteFiles() helper reads the files of a paste from the body of
+// the request, which is either a multipart upload or the content of a single
+// file. The content must be UTF-8 text. Like readJSON(), the errors it
+// returns are meant for clients.
+func readPasteFiles(w http.ResponseWriter, r *http.Request) ([]snippetFileForm, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxPasteBytes)
+
+	// Raw bodies sent with "curl --data-binary" are labelled as form data,
+	// so anything which isn't a multipart upload is taken as is.
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		content, err := io.ReadAll(r.Body)
+		if err != nil {
+			return nil, pasteBodyError(err, "body could not be read")
+		}
+
+		if !utf8.Valid(content) {
+			return nil, errors.New("body must be UTF-8 text")
+		}
+
+		return []snippetFileForm{{Name: r.URL.Query().Get("name"), Content: string(content)}}, nil
+	}
+
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, errors.New("body contains a badly-formed multipart upload")
+	}
+
+	var files []snippetFileForm
+
+	for {
+		part, err := mr.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, pasteBodyError(err, "body contains a badly-formed multipart upload")
+		}
+
+		// Only uploaded files and the fields named "f" or "file", like with
+		// "curl -F 'f=<file'", are pasted. Other fields are ignored.
+		if part.FileName() == "" && part.FormName() != "f" && part.FormName() != "file" {
+			continue
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, pasteBodyError(err, "body contains a badly-formed multipart upload")
+		}
+
+		if !utf8.Valid(content) {
+			return nil, fmt.Errorf("part %q must be UTF-8 text", part.FormName())
+		}
+
+		// curl names the content read from stdin "-", which isn't a useful
+		// file name, so let the snippet name it instead.
+		name := part.FileName()
+		if name == "-" {
+			name = ""
+		}
+
+		files = append(files, snippetFileForm{Name: name, Content: string(content)})
+	}
+
+	return files, nil
+}
+
+// The pasteBodyError() helper converts an error reading the body of a paste
+// into an error for the client, which is the given message unless the body
+// was too large.
+func pasteBodyError(err error, message string) error {
+	var maxBytesError *http.MaxBytesError
+	if errors.As(err, &maxBytesError) {
+		return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+	}
+
+	return errors.New(message)
+}
+
+// The failedValidationText() helper sends a 422 Unprocessable Entity
+// response listing the errors found by a validator as plain text, one per
+// line, in order of field name.
+func failedValidationText(w http.ResponseWriter, v validator.Validator) {
+	lines := []string{"The paste contains invalid data:"}
+
+	for _, field := range slices.Sorted(maps.Keys(v.FieldErrors)) {
+		lines = append(lines, fmt.Sprintf("  %s: %s", field, v.FieldErrors[field]))
+	}
+
+	for _, message := range v.NonFieldErrors {
+		lines = append(lines, "  "+message)
+	}
+
+	http.Error(w, strings.Join(lines, "\n"), http.StatusUnprocessableEntity)
+}
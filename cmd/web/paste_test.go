@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+	"github.com/Overlrd/snippetbox/internal/models"
+)
+
+// The pastedSnippetModel type records the snippet created by Insert(), and
+// lets it be fetched afterwards like createdSnippetModel.
+type pastedSnippetModel struct {
+	createdSnippetModel
+	userID     int
+	title      string
+	files      []models.File
+	visibility string
+	burn       bool
+}
+
+func (m *pastedSnippetModel) Insert(userID int, title string, files []models.File, visibility string, expires time.Time, burn bool) (int, error) {
+	m.userID, m.title, m.files, m.visibility, m.burn = userID, title, files, visibility, burn
+	return 2, nil
+}
+
+// The multipartBody helper encodes files, given as name and content pairs,
+// like "curl -F" uploads them, and returns the body and its content type.
+// Names ending with "=", like "title=", are sent as plain fields instead.
+func multipartBody(t *testing.T, files ...string) (string, string) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	for i := 0; i < len(files); i += 2 {
+		var w io.Writer
+		var err error
+
+		if field, ok := strings.CutSuffix(files[i], "="); ok {
+			w, err = mw.CreateFormField(field)
+		} else {
+			w, err = mw.CreateFormFile("f", files[i])
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(files[i+1]))
+	}
+
+	err := mw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.String(), mw.FormDataContentType()
+}
+
+func TestPaste(t *testing.T) {
+	uploadBody, uploadType := multipartBody(t, "main.go", "package main", "-", "Hello!")
+	fieldsBody, fieldsType := multipartBody(t, "title=", "Not a file", "f=", "Hello!")
+
+	tests := []struct {
+		name             string
+		token            string
+		anonPastes       bool
+		query            string
+		contentType      string
+		body             string
+		wantCode         int
+		wantBody         string
+		wantUserID       int
+		wantTitle        string
+		wantFiles        string
+		wantVisibility   string
+		wantBurn         bool
+		wantAuthenticate string
+	}{
+		{
+			name:           "Raw body",
+			token:          "sbx_alice",
+			query:          "?name=hello.py&expires=burn",
+			contentType:    "application/x-www-form-urlencoded",
+			body:           "print('hello')\n",
+			wantCode:       http.StatusCreated,
+			wantBody:       "/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI",
+			wantUserID:     1,
+			wantTitle:      "hello.py",
+			wantFiles:      "hello.py python",
+			wantVisibility: models.VisibilityPublic,
+			wantBurn:       true,
+		},
+		{
+			name:           "Raw body without a name",
+			token:          "sbx_bob",
+			query:          "?title=Build+log&visibility=unlisted",
+			body:           "ok",
+			wantCode:       http.StatusCreated,
+			wantUserID:     2,
+			wantTitle:      "Build log",
+			wantFiles:      "file1.txt text",
+			wantVisibility: models.VisibilityUnlisted,
+		},
+		{
+			name:           "Multipart upload",
+			token:          "sbx_alice",
+			query:          "?language=text",
+			contentType:    uploadType,
+			body:           uploadBody,
+			wantCode:       http.StatusCreated,
+			wantUserID:     1,
+			wantTitle:      "main.go",
+			wantFiles:      "main.go text, file2.txt text",
+			wantVisibility: models.VisibilityPublic,
+		},
+		{
+			name:           "Multipart fields",
+			token:          "sbx_alice",
+			contentType:    fieldsType,
+			body:           fieldsBody,
+			wantCode:       http.StatusCreated,
+			wantUserID:     1,
+			wantTitle:      "Untitled paste",
+			wantFiles:      "file1.txt text",
+			wantVisibility: models.VisibilityPublic,
+		},
+		{
+			name:           "Anonymous",
+			anonPastes:     true,
+			body:           "Hello!",
+			wantCode:       http.StatusCreated,
+			wantTitle:      "Untitled paste",
+			wantFiles:      "file1.txt text",
+			wantVisibility: models.VisibilityPublic,
+		},
+		{
+			name:             "Anonymous not allowed",
+			body:             "Hello!",
+			wantCode:         http.StatusUnauthorized,
+			wantBody:         `"error": "you must paste with an API token`,
+			wantAuthenticate: "Bearer",
+		},
+		{
+			name:             "Invalid token",
+			token:            "sbx_invalid",
+			body:             "Hello!",
+			wantCode:         http.StatusUnauthorized,
+			wantBody:         `"error": "invalid or expired authentication token"`,
+			wantAuthenticate: `Bearer error="invalid_token"`,
+		},
+		{
+			name:       "Anonymous private",
+			anonPastes: true,
+			query:      "?visibility=private",
+			body:       "Hello!",
+			wantCode:   http.StatusUnprocessableEntity,
+			wantBody:   "visibility: Anonymous snippets cannot be private",
+		},
+		{
+			name:     "Empty body",
+			token:    "sbx_alice",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "files[0].content: This field cannot be blank",
+		},
+		{
+			name:     "Invalid expiry",
+			token:    "sbx_alice",
+			query:    "?expires=3",
+			body:     "Hello!",
+			wantCode: http.StatusUnprocessableEntity,
+			wantBody: "expires: This field must equal 1, 7, 365, never, custom or burn",
+		},
+		{
+			name:     "Binary body",
+			token:    "sbx_alice",
+			body:     "\xff\xfe",
+			wantCode: http.StatusBadRequest,
+			wantBody: "body must be UTF-8 text",
+		},
+		{
+			name:     "Too large",
+			token:    "sbx_alice",
+			body:     strings.Repeat("a", maxPasteBytes+1),
+			wantCode: http.StatusBadRequest,
+			wantBody: "body must not be larger than 1048576 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApplication(t)
+			app.anonPastes = tt.anonPastes
+
+			snippets := &pastedSnippetModel{}
+			app.snippets = snippets
+
+			ts := newTestServer(t, app.routes())
+			defer ts.Close()
+
+			header := http.Header{}
+			if tt.contentType != "" {
+				header.Set("Content-Type", tt.contentType)
+			}
+			if tt.token != "" {
+				header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			code, header, body := ts.do(t, http.MethodPost, "/"+tt.query, strings.NewReader(tt.body), header)
+
+			assert.Equal(t, code, tt.wantCode)
+			if tt.wantBody != "" {
+				assert.StringContains(t, body, tt.wantBody)
+			}
+
+			// Both a missing and an invalid token ask for a bearer token.
+			assert.Equal(t, header.Get("WWW-Authenticate"), tt.wantAuthenticate)
+
+			if tt.wantCode != http.StatusCreated {
+				assert.Equal(t, snippets.title, "")
+				return
+			}
+
+			// The URL uses the configured base URL, whatever the Host header.
+			url := "https://snippetbox.example.com/snippet/view/Y3JlYXRlZC1zbmlwcGV0LTI"
+			assert.Equal(t, body, url)
+			assert.Equal(t, header.Get("Location"), url)
+
+			var files []string
+			for _, f := range snippets.files {
+				files = append(files, f.Name+" "+f.Language)
+			}
+
+			assert.Equal(t, snippets.userID, tt.wantUserID)
+			assert.Equal(t, snippets.title, tt.wantTitle)
+			assert.Equal(t, strings.Join(files, ", "), tt.wantFiles)
+			assert.Equal(t, snippets.visibility, tt.wantVisibility)
+			assert.Equal(t, snippets.burn, tt.wantBurn)
+		})
+	}
+}
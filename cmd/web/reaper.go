@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// The reapExpiredSnippets() method deletes expired snippets every interval
+// until the context is cancelled. It is meant to be run in its own
+// goroutine, and returns once any batch in progress has finished.
+func (app *application) reapExpiredSnippets(ctx context.Context, interval time.Duration, batchSize int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			app.reap(ctx, batchSize)
+		}
+	}
+}
+
+// The reap() method deletes expired snippets in batches of batchSize, until
+// a batch comes back short or the context is cancelled, and logs how many
+// were deleted in total.
+func (app *application) reap(ctx context.Context, batchSize int) {
+	total := 0
+
+	for ctx.Err() == nil {
+		n, err := app.snippets.DeleteExpired(batchSize)
+		if err != nil {
+			app.logger.Error("deleting expired snippets", "error", err.Error(), "deleted", total)
+			return
+		}
+
+		total += n
+
+		if n < batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		app.logger.Info("deleted expired snippets", "count", total)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+	"github.com/Overlrd/snippetbox/internal/models/mocks"
+)
+
+// The expiringSnippetModel type pretends to hold a number of expired
+// snippets, and records the batches in which they are deleted.
+type expiringSnippetModel struct {
+	mocks.SnippetModel
+	expired int
+	err     error
+	batches []int
+	deleted chan int
+}
+
+func (m *expiringSnippetModel) DeleteExpired(limit int) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+
+	n := min(limit, m.expired)
+	m.expired -= n
+	m.batches = append(m.batches, n)
+
+	// Let a waiting test know about the first snippets deleted, without
+	// blocking on the later, empty batches.
+	if m.deleted != nil && n > 0 {
+		m.deleted <- n
+	}
+
+	return n, nil
+}
+
+func TestReap(t *testing.T) {
+	tests := []struct {
+		name        string
+		expired     int
+		err         error
+		wantBatches []int
+	}{
+		{
+			name:        "Nothing expired",
+			expired:     0,
+			wantBatches: []int{0},
+		},
+		{
+			name:        "Fewer than a batch",
+			expired:     3,
+			wantBatches: []int{3},
+		},
+		{
+			name:        "Several batches",
+			expired:     25,
+			wantBatches: []int{10, 10, 5},
+		},
+		{
+			name:        "Exact batches",
+			expired:     20,
+			wantBatches: []int{10, 10, 0},
+		},
+		{
+			name:        "Database error",
+			expired:     25,
+			err:         errors.New("connection refused"),
+			wantBatches: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snippets := &expiringSnippetModel{expired: tt.expired, err: tt.err}
+
+			app := newTestApplication(t)
+			app.snippets = snippets
+
+			app.reap(context.Background(), 10)
+
+			assert.Equal(t, len(snippets.batches), len(tt.wantBatches))
+			for i := range tt.wantBatches {
+				assert.Equal(t, snippets.batches[i], tt.wantBatches[i])
+			}
+		})
+	}
+}
+
+func TestReapExpiredSnippets(t *testing.T) {
+	snippets := &expiringSnippetModel{expired: 5, deleted: make(chan int, 1)}
+
+	app := newTestApplication(t)
+	app.snippets = snippets
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		app.reapExpiredSnippets(ctx, time.Millisecond, 10)
+		close(done)
+	}()
+
+	select {
+	case n := <-snippets.deleted:
+		assert.Equal(t, n, 5)
+	case <-time.After(time.Second):
+		t.Fatal("expired snippets were not deleted")
+	}
+
+	// Once the context is cancelled the reaper should stop.
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("reaper did not stop")
+	}
+}
@@ -25,19 +25,97 @@ func (app *application) routes() http.Handler {
 	// Update these routes to use the nes dynamic middleware chain
 	mux.Handle("GET /{$}", dynamic.ThenFunc(app.home))
 	mux.Handle("GET /snippet/view/{id}", dynamic.ThenFunc(app.snippetView))
+	mux.Handle("POST /snippet/view/{id}", dynamic.ThenFunc(app.snippetReveal))
+	mux.Handle("GET /search", dynamic.ThenFunc(app.search))
+	mux.Handle("GET /tag/{name}", dynamic.ThenFunc(app.tagView))
+	mux.Handle("GET /collection/{slug}", dynamic.ThenFunc(app.collectionView))
 	mux.Handle("GET /user/signup", dynamic.ThenFunc(app.getUserSignup))
 	mux.Handle("POST /user/signup", dynamic.ThenFunc(app.postUserSignup))
 	mux.Handle("GET /user/login", dynamic.ThenFunc(app.getUserLogin))
 	mux.Handle("POST /user/login", dynamic.ThenFunc(app.postUserLogin))
 
+	// Raw and downloaded snippets may be stored by shared caches, so their
+	// responses must not set cookies. They only need the session to let
+	// owners read their private snippets, and as they are never forms they
+	// go without the CSRF cookie of noSurf. The session cookie is only set
+	// when the session changes, which these handlers don't do.
+	raw := alice.New(app.sessionManager.LoadAndSave, app.authenticate)
+
+	mux.Handle("GET /snippet/raw/{id}", raw.ThenFunc(app.snippetRaw))
+	mux.Handle("GET /snippet/raw/{id}/{file}", raw.ThenFunc(app.snippetRaw))
+	mux.Handle("GET /snippet/download/{id}", raw.ThenFunc(app.snippetDownload))
+	mux.Handle("GET /snippet/download/{id}/{file}", raw.ThenFunc(app.snippetDownload))
+
+	// The pages nested under a snippet, like /snippet/{id}/history, can't
+	// have patterns of their own as ServeMux would reject them as conflicting
+	// with /snippet/raw/{id} and the like. Instead they share a pattern which
+	// is less specific than those routes, and subpages() picks the handler.
+	mux.Handle("GET /snippet/{id}/{page}", dynamic.Then(subpages(map[string]http.HandlerFunc{
+		"history": app.snippetHistory,
+		"diff":    app.snippetDiff,
+	})))
+
 	// Protected (authenticated-only) application routes, using a new "protected"
 	// middleware chain which includes the requireAuthentication middleware.
 	protected := dynamic.Append(app.requireAuthentication)
 
 	mux.Handle("GET /snippet/create", protected.ThenFunc(app.getSnippetCreate))
 	mux.Handle("POST /snippet/create", protected.ThenFunc(app.postSnippetCreate))
+	mux.Handle("POST /snippet/fork/{id}", protected.ThenFunc(app.postSnippetFork))
+	mux.Handle("POST /snippet/collect/{id}", protected.ThenFunc(app.postSnippetCollect))
+	mux.Handle("GET /collection/create", protected.ThenFunc(app.getCollectionCreate))
+	mux.Handle("POST /collection/create", protected.ThenFunc(app.postCollectionCreate))
+	mux.Handle("GET /collection/edit/{slug}", protected.ThenFunc(app.getCollectionEdit))
+	mux.Handle("POST /collection/edit/{slug}", protected.ThenFunc(app.postCollectionEdit))
+	mux.Handle("POST /snippet/comment/{id}", protected.ThenFunc(app.postCommentCreate))
+	mux.Handle("POST /comment/delete/{id}", protected.ThenFunc(app.postCommentDelete))
+
+	// Like the pages nested under a snippet, the actions nested under it
+	// share a single pattern.
+	mux.Handle("POST /snippet/{id}/{page}", protected.Then(subpages(map[string]http.HandlerFunc{
+		"star":   app.postSnippetStar,
+		"unstar": app.postSnippetUnstar,
+	})))
+	mux.Handle("GET /user/stars", protected.ThenFunc(app.userStars))
+	mux.Handle("GET /user/collections", protected.ThenFunc(app.userCollections))
+	mux.Handle("GET /user/tokens", protected.ThenFunc(app.userTokens))
+	mux.Handle("POST /user/tokens", protected.ThenFunc(app.postTokenCreate))
+	mux.Handle("POST /token/delete/{id}", protected.ThenFunc(app.postTokenDelete))
 	mux.Handle("POST /user/logout", protected.ThenFunc(app.postUserLogout))
 
+	// Routes which change an existing snippet are further restricted to the
+	// owner of that snippet.
+	owner := protected.Append(app.requireSnippetOwner)
+
+	mux.Handle("GET /snippet/edit/{id}", owner.ThenFunc(app.getSnippetEdit))
+	mux.Handle("POST /snippet/edit/{id}", owner.ThenFunc(app.postSnippetEdit))
+
+	// Deleting a snippet is also allowed for administrators.
+	ownerOrAdmin := protected.Append(app.requireSnippetOwnerOrAdmin)
+
+	mux.Handle("GET /snippet/delete/{id}", ownerOrAdmin.ThenFunc(app.getSnippetDelete))
+	mux.Handle("POST /snippet/delete/{id}", ownerOrAdmin.ThenFunc(app.postSnippetDelete))
+
+	// The JSON API is used by scripts rather than browsers, so it doesn't
+	// use sessions or the cookie-based CSRF protection of the noSurf
+	// middleware. Clients authenticate with an API token on every request
+	// instead.
+	api := alice.New(app.authenticateToken)
+
+	mux.Handle("GET /api/v1/snippets", api.ThenFunc(app.apiSnippetList))
+	mux.Handle("GET /api/v1/snippets/{id}", api.ThenFunc(app.apiSnippetGet))
+
+	apiProtected := api.Append(app.requireAPIAuthentication)
+
+	mux.Handle("POST /api/v1/snippets", apiProtected.ThenFunc(app.apiSnippetCreate))
+	mux.Handle("PATCH /api/v1/snippets/{id}", apiProtected.ThenFunc(app.apiSnippetUpdate))
+	mux.Handle("DELETE /api/v1/snippets/{id}", apiProtected.ThenFunc(app.apiSnippetDelete))
+
+	// Pasting from the command line authenticates like the API, but sends
+	// plain text back. Whether it requires a token is checked by the handler,
+	// as anonymous pastes can be allowed.
+	mux.Handle("POST /{$}", api.ThenFunc(app.postPaste))
+
 	// Create a middleware chain containing our 'standard' middleware
 	// which will be used for every request our application receives.
 	standard := alice.New(app.recoverPanic, app.logRequest, commonHeaders)
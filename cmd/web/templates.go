@@ -1,25 +1,54 @@
 package main
 
 import (
+	"fmt"
 	"html/template"
 	"io/fs"
+	"net/url"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/Overlrd/snippetbox/internal/markdown"
 	"github.com/Overlrd/snippetbox/internal/models"
+	"github.com/Overlrd/snippetbox/internal/syntax"
 	"github.com/Overlrd/snippetbox/ui"
 )
 
 // Define a templateData type to act as the holding structure for
 // any dynamic data that we want to pass to our HTML templates.
 type templateData struct {
-	CurrentYear     int
-	Snippet         models.Snippet
-	Snippets        []models.Snippet
-	Form            any
-	Flash           string
-	IsAuthenticated bool
-	CSRFToken       string
+	CurrentYear         int
+	Snippet             models.Snippet
+	Parent              models.Snippet
+	UnlistedParent      bool
+	Snippets            []models.Snippet
+	Pagination          pagination
+	Tag                 string
+	TagCloud            []models.Tag
+	MostViewed          []models.Snippet
+	Form                any
+	Flash               string
+	IsAuthenticated     bool
+	AuthenticatedUserID int
+	IsAdmin             bool
+	CSRFToken           string
+	SearchQuery         string
+	Revisions           []models.Revision
+	FromRevision        models.Revision
+	ToRevision          models.Revision
+	Diff                []fileDiff
+	Comments            []models.Comment
+	LineComments        map[string]map[int][]models.Comment
+	Starred             bool
+	Collection          models.Collection
+	Collections         []models.Collection
+	UserCollections     []models.Collection
+	Tokens              []models.Token
+	NewToken            string
 }
 
 // Create a humanDate function which returns a nicely formatted string
@@ -34,11 +63,185 @@ func humanDate(t time.Time) string {
 	return t.UTC().Format("02 Jan 2006 at 15:04")
 }
 
+// The maximum number of characters of snippet content shown by excerpt().
+const excerptLength = 200
+
+// searchTermsRX() returns a case-insensitive regular expression matching any
+// of the whitespace-separated terms in a search query, or nil if the query
+// is blank. Longer terms are tried first so that they take precedence.
+func searchTermsRX(query string) *regexp.Regexp {
+	terms := strings.Fields(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(terms, func(a, b string) int {
+		return len(b) - len(a)
+	})
+
+	for i, term := range terms {
+		terms[i] = regexp.QuoteMeta(term)
+	}
+
+	return regexp.MustCompile("(?i)" + strings.Join(terms, "|"))
+}
+
+// Create a highlight function which HTML-escapes the text and wraps each
+// occurrence of the search query terms in a <mark> element.
+func highlight(query, text string) template.HTML {
+	rx := searchTermsRX(query)
+	if rx == nil {
+		return template.HTML(template.HTMLEscapeString(text))
+	}
+
+	var b strings.Builder
+	last := 0
+
+	for _, loc := range rx.FindAllStringIndex(text, -1) {
+		b.WriteString(template.HTMLEscapeString(text[last:loc[0]]))
+		b.WriteString("<mark>")
+		b.WriteString(template.HTMLEscapeString(text[loc[0]:loc[1]]))
+		b.WriteString("</mark>")
+		last = loc[1]
+	}
+	b.WriteString(template.HTMLEscapeString(text[last:]))
+
+	return template.HTML(b.String())
+}
+
+// Create an excerpt function which shortens the text to at most
+// excerptLength characters, centred loosely on the first occurrence of the
+// search query terms.
+func excerpt(query, text string) string {
+	runes := []rune(text)
+	if len(runes) <= excerptLength {
+		return text
+	}
+
+	start := 0
+	if rx := searchTermsRX(query); rx != nil {
+		if loc := rx.FindStringIndex(text); loc != nil {
+			start = max(utf8.RuneCountInString(text[:loc[0]])-excerptLength/4, 0)
+		}
+	}
+
+	end := min(start+excerptLength, len(runes))
+	start = max(end-excerptLength, 0)
+
+	s := string(runes[start:end])
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(runes) {
+		s += "…"
+	}
+
+	return s
+}
+
+// Create a tagWeight function which ranks a tag from 1 to 5 according to its
+// count relative to the most used tag, for sizing the tag cloud.
+func tagWeight(count, maxCount int) int {
+	if maxCount < 1 {
+		return 1
+	}
+
+	return 1 + (count*4)/maxCount
+}
+
+// Create a languages function which returns the languages a snippet can be
+// highlighted as, for building the language select box.
+func languages() []syntax.Language {
+	return syntax.Languages
+}
+
+// Create a snippetURL function which returns the address a snippet should be
+// shared with: the slug for unlisted snippets, otherwise the numeric ID.
+func snippetURL(s models.Snippet) string {
+	if s.Visibility == models.VisibilityUnlisted {
+		return "/snippet/view/" + s.Slug
+	}
+
+	return fmt.Sprintf("/snippet/view/%d", s.ID)
+}
+
+// Create a snippetActionURL function which returns the address of another
+// page for a snippet, such as "raw" or "history", using the same identifier
+// as snippetURL.
+func snippetActionURL(kind string, s models.Snippet) string {
+	return strings.Replace(snippetURL(s), "/snippet/view/", "/snippet/"+kind+"/", 1)
+}
+
+// Create a snippetPageURL function which returns the address of a page
+// which is nested under a snippet, such as "/snippet/42/history", using the
+// same identifier as snippetURL.
+func snippetPageURL(page string, s models.Snippet) string {
+	return "/snippet/" + strings.TrimPrefix(snippetURL(s), "/snippet/view/") + "/" + page
+}
+
+// Create a tagURL function which returns the address of the page of a tag.
+// Tags may contain a '#', which html/template leaves alone in URLs, so the
+// name is escaped to stop browsers from taking the rest as a fragment.
+func tagURL(name string) string {
+	return "/tag/" + url.PathEscape(name)
+}
+
+// Define a codeLine type to hold a numbered line of a highlighted file.
+type codeLine struct {
+	Number int
+	HTML   template.HTML
+}
+
+// Create a codeLines function which highlights a file and splits it into
+// numbered lines, so that each line can be linked to and commented on.
+func codeLines(f models.File) ([]codeLine, error) {
+	lines, err := syntax.Lines(f.Language, f.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	numbered := make([]codeLine, len(lines))
+	for i, html := range lines {
+		numbered[i] = codeLine{Number: i + 1, HTML: html}
+	}
+
+	return numbered, nil
+}
+
+// Create a lineID function which returns the HTML id of a line of the file
+// at the given position in a snippet. Lines of the first file are simply
+// "L10", so that "#L10-L20" links work for single-file snippets, and lines
+// of other files are prefixed with the file name, as in "main.go-L10".
+func lineID(fileIndex int, fileName string, number int) string {
+	if fileIndex == 0 {
+		return fmt.Sprintf("L%d", number)
+	}
+
+	return fmt.Sprintf("%s-L%d", fileName, number)
+}
+
 // Initialize a template.FuncMap object and store it in a global variable.
 // This is a string-keyed map which acts as a lookup between the names of
 // the custom template functions and the functions themselves.
 var functions = template.FuncMap{
 	"humanDate": humanDate,
+	"highlight": highlight,
+	"excerpt":   excerpt,
+	"tagWeight": tagWeight,
+	// Highlighting returns template.HTML with the content already escaped.
+	"codeLines":        codeLines,
+	"lineID":           lineID,
+	"languageLabel":    syntax.Label,
+	"languages":        languages,
+	"snippetURL":       snippetURL,
+	"snippetActionURL": snippetActionURL,
+	"snippetPageURL":   snippetPageURL,
+	"tagURL":           tagURL,
+	// Checks whether a list of IDs, such as a multi-valued form field,
+	// includes an ID.
+	"contains": slices.Contains[[]int],
+	// Markdown is sanitized and returned as template.HTML.
+	"markdown": markdown.HTML,
 }
 
 func newTemplateCache() (map[string]*template.Template, error) {
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 	"time"
 
@@ -40,3 +41,86 @@ func TestHumanDate(t *testing.T) {
 		})
 	}
 }
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		text  string
+		want  string
+	}{
+		{
+			name:  "No query",
+			query: "",
+			text:  "An old silent pond",
+			want:  "An old silent pond",
+		},
+		{
+			name:  "Case insensitive",
+			query: "POND",
+			text:  "An old silent pond",
+			want:  "An old silent <mark>pond</mark>",
+		},
+		{
+			name:  "Multiple terms",
+			query: "old pond",
+			text:  "An old silent pond",
+			want:  "An <mark>old</mark> silent <mark>pond</mark>",
+		},
+		{
+			name:  "Escapes HTML",
+			query: "b",
+			text:  "<b>bold</b>",
+			want:  "&lt;<mark>b</mark>&gt;<mark>b</mark>old&lt;/<mark>b</mark>&gt;",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, string(highlight(tt.query, tt.text)), tt.want)
+		})
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	short := "An old silent pond"
+	assert.Equal(t, excerpt("pond", short), short)
+
+	long := strings.Repeat("a", 300) + " frog " + strings.Repeat("b", 300)
+	got := excerpt("frog", long)
+
+	assert.StringContains(t, got, "frog")
+	assert.Equal(t, strings.HasPrefix(got, "…"), true)
+	assert.Equal(t, strings.HasSuffix(got, "…"), true)
+	assert.Equal(t, len([]rune(got)), excerptLength+2)
+}
+
+func TestTagURL(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want string
+	}{
+		{
+			name: "Plain",
+			tag:  "haiku",
+			want: "/tag/haiku",
+		},
+		{
+			name: "Hash",
+			tag:  "c#",
+			want: "/tag/c%23",
+		},
+		{
+			name: "Plus",
+			tag:  "c++",
+			want: "/tag/c++",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tagURL(tt.tag), tt.want)
+		})
+	}
+}
@@ -2,11 +2,15 @@ package main
 
 import (
 	"bytes"
+	"html"
 	"io"
 	"log/slog"
 	"net/http"
 	"net/http/cookiejar"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +19,23 @@ import (
 	"github.com/go-playground/form/v4"
 )
 
+// Define a regular expression which captures the CSRF token value from the
+// HTML for our pages.
+var csrfTokenRX = regexp.MustCompile(`<input type='hidden' name='csrf_token' value='(.+)'>`)
+
+func extractCSRFToken(t *testing.T, body string) string {
+	// Use the FindStringSubmatch method to extract the token from the HTML body.
+	// Note that this returns an array with the entire matched pattern in the
+	// first position, and the values of any captured data in the subsequent
+	// positions.
+	matches := csrfTokenRX.FindStringSubmatch(body)
+	if len(matches) < 2 {
+		t.Fatal("no csrf token found in body")
+	}
+
+	return html.UnescapeString(matches[1])
+}
+
 // Create a newTestApplication helper which returns an instance of our
 // application struct containing mocked dependencies.
 func newTestApplication(t *testing.T) *application {
@@ -36,9 +57,19 @@ func newTestApplication(t *testing.T) *application {
 		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
 		snippets:       &mocks.SnippetModel{},
 		users:          &mocks.UserModel{},
+		tags:           &mocks.TagModel{},
+		revisions:      &mocks.RevisionModel{},
+		comments:       &mocks.CommentModel{},
+		stars:          &mocks.StarModel{},
+		collections:    &mocks.CollectionModel{},
+		tokens:         &mocks.TokenModel{},
+		views:          &mocks.ViewModel{},
+		viewCounter:    newViewCounter(&mocks.ViewModel{}),
 		templateCache:  templateCache,
 		formDecoder:    formDecoder,
 		sessionManager: sessionManager,
+		maxExpiry:      365 * 24 * time.Hour,
+		baseURL:        "https://snippetbox.example.com",
 	}
 }
 
@@ -90,3 +121,84 @@ func (ts *testServer) get(t *testing.T, urlPath string) (int, http.Header, strin
 
 	return rs.StatusCode, rs.Header, string(body)
 }
+
+// Create a postForm method for sending POST requests to the test server. The
+// final parameter to this method is a url.Values object which can contain any
+// form data that you want to send in the request body.
+func (ts *testServer) postForm(t *testing.T, urlPath string, form url.Values) (int, http.Header, string) {
+	req, err := http.NewRequest(http.MethodPost, ts.URL+urlPath, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	// Set the Origin header like a browser would, so that the same-origin
+	// check performed by the noSurf middleware passes.
+	req.Header.Set("Origin", ts.URL)
+
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the response body from the test server.
+	defer rs.Body.Close()
+	body, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = bytes.TrimSpace(body)
+
+	// Return the response status, headers and body.
+	return rs.StatusCode, rs.Header, string(body)
+}
+
+// The login helper logs the test server client in as the mock user with the
+// given credentials and returns a fresh CSRF token for subsequent requests.
+func (ts *testServer) login(t *testing.T, email, password string) string {
+	_, _, body := ts.get(t, "/user/login")
+	csrfToken := extractCSRFToken(t, body)
+
+	form := url.Values{}
+	form.Add("email", email)
+	form.Add("password", password)
+	form.Add("csrf_token", csrfToken)
+
+	code, _, _ := ts.postForm(t, "/user/login", form)
+	if code != http.StatusSeeOther {
+		t.Fatalf("login as %s failed with status %d", email, code)
+	}
+
+	// Fetch the create snippet page, which is only available to authenticated
+	// users, to get a CSRF token for subsequent requests.
+	_, _, body = ts.get(t, "/snippet/create")
+	return extractCSRFToken(t, body)
+}
+
+// The do method sends a request built by the test, such as an API request
+// with a JSON body, to the test server and returns the response status
+// code, headers and body.
+func (ts *testServer) do(t *testing.T, method, urlPath string, body io.Reader, header http.Header) (int, http.Header, string) {
+	req, err := http.NewRequest(method, ts.URL+urlPath, body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	rs, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rs.Body.Close()
+	respBody, err := io.ReadAll(rs.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	respBody = bytes.TrimSpace(respBody)
+
+	return rs.StatusCode, rs.Header, string(respBody)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Overlrd/snippetbox/internal/models"
+)
+
+// The viewCounter type buffers views of snippets in memory, so that the
+// database is only written to when the counts are flushed rather than on
+// every request. It is safe for concurrent use.
+type viewCounter struct {
+	mu     sync.Mutex
+	counts map[int]int
+	views  models.ViewModelInterface
+}
+
+func newViewCounter(views models.ViewModelInterface) *viewCounter {
+	return &viewCounter{
+		counts: map[int]int{},
+		views:  views,
+	}
+}
+
+// The add() method records a view of a snippet.
+func (c *viewCounter) add(snippetID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[snippetID]++
+}
+
+// The flush() method writes the views recorded since the last flush to the
+// database. If that fails the views are kept, to be written next time.
+func (c *viewCounter) flush() error {
+	c.mu.Lock()
+	counts := c.counts
+	c.counts = map[int]int{}
+	c.mu.Unlock()
+
+	if len(counts) == 0 {
+		return nil
+	}
+
+	err := c.views.Add(counts)
+	if err != nil {
+		c.mu.Lock()
+		for snippetID, n := range c.counts {
+			counts[snippetID] += n
+		}
+		c.counts = counts
+		c.mu.Unlock()
+	}
+
+	return err
+}
+
+// The flushViews() method flushes the view counter every interval until the
+// context is cancelled. It is meant to be run in its own goroutine; views
+// recorded after the last flush are written by main() once the server has
+// stopped.
+func (app *application) flushViews(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := app.viewCounter.flush()
+			if err != nil {
+				app.logger.Error("flushing snippet views", "error", err.Error())
+			}
+		}
+	}
+}
+
+// The maximum number of snippets remembered as viewed in a session. Older
+// ones are forgotten, and counted again if they are viewed again.
+const maxViewedSnippets = 100
+
+// The countView() helper records a view of a snippet, unless it was already
+// viewed in the current session or the viewer is its owner.
+func (app *application) countView(r *http.Request, snippet models.Snippet) {
+	if app.isSnippetOwner(r, snippet) {
+		return
+	}
+
+	viewed, _ := app.sessionManager.Get(r.Context(), "viewedSnippets").([]int)
+	if slices.Contains(viewed, snippet.ID) {
+		return
+	}
+
+	viewed = append(viewed, snippet.ID)
+	if len(viewed) > maxViewedSnippets {
+		viewed = slices.Clone(viewed[len(viewed)-maxViewedSnippets:])
+	}
+	app.sessionManager.Put(r.Context(), "viewedSnippets", viewed)
+
+	app.viewCounter.add(snippet.ID)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+	"github.com/Overlrd/snippetbox/internal/models/mocks"
+)
+
+// The recordingViewModel type records the views written to it, and fails
+// if err is set.
+type recordingViewModel struct {
+	mocks.ViewModel
+	err    error
+	counts []map[int]int
+}
+
+func (m *recordingViewModel) Add(counts map[int]int) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	m.counts = append(m.counts, counts)
+	return nil
+}
+
+func TestViewCounterFlush(t *testing.T) {
+	views := &recordingViewModel{}
+	c := newViewCounter(views)
+
+	// Nothing is written if there were no views.
+	err := c.flush()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(views.counts), 0)
+
+	c.add(1)
+	c.add(1)
+	c.add(3)
+
+	err = c.flush()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(views.counts), 1)
+	assert.Equal(t, views.counts[0][1], 2)
+	assert.Equal(t, views.counts[0][3], 1)
+	assert.Equal(t, len(c.counts), 0)
+
+	// Views which couldn't be written are kept for the next flush.
+	c.add(1)
+	views.err = errors.New("connection refused")
+
+	err = c.flush()
+	assert.Equal(t, err, views.err)
+
+	c.add(1)
+	views.err = nil
+
+	err = c.flush()
+	assert.Equal(t, err, nil)
+	assert.Equal(t, len(views.counts), 2)
+	assert.Equal(t, views.counts[1][1], 2)
+}
@@ -2,14 +2,19 @@ module github.com/Overlrd/snippetbox
 
 go 1.25.5
 
-require github.com/go-sql-driver/mysql v1.9.3
+require (
+	github.com/alecthomas/chroma/v2 v2.27.0
+	github.com/alexedwards/scs/mysqlstore v0.0.0-20251002162104-209de6e426de
+	github.com/alexedwards/scs/v2 v2.9.0
+	github.com/go-playground/form/v4 v4.3.0
+	github.com/go-sql-driver/mysql v1.9.3
+	github.com/justinas/alice v1.2.0
+	github.com/justinas/nosurf v1.2.0
+	github.com/yuin/goldmark v1.8.6
+	golang.org/x/crypto v0.47.0
+)
 
 require (
 	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/alexedwards/scs/mysqlstore v0.0.0-20251002162104-209de6e426de // indirect
-	github.com/alexedwards/scs/v2 v2.9.0 // indirect
-	github.com/go-playground/form/v4 v4.3.0 // indirect
-	github.com/justinas/alice v1.2.0 // indirect
-	github.com/justinas/nosurf v1.2.0 // indirect
-	golang.org/x/crypto v0.47.0 // indirect
+	github.com/dlclark/regexp2/v2 v2.2.1 // indirect
 )
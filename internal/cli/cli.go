@@ -0,0 +1,384 @@
+// Package cli implements the snippet command, which creates, reads, lists
+// and deletes snippets on a snippetbox server through its JSON API. It lives
+// outside cmd/snippet so that the web application's tests can run it
+// against the real handlers.
+//
+// Usage:
+//
+//	snippet [-config file] [-server url] [-token token] command [arguments]
+//
+// The commands are:
+//
+//	push [file...]  create a snippet from files, or from stdin
+//	get id          print the files of a snippet
+//	ls              list the public snippets
+//	rm id           delete a snippet
+//
+// The server and the API token are read from a config file, which is
+// snippet/config.json in the user's config directory by default, and can be
+// overridden by the SNIPPET_SERVER and SNIPPET_TOKEN environment variables
+// and then by the flags.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Overlrd/snippetbox/internal/client"
+	"github.com/Overlrd/snippetbox/internal/syntax"
+)
+
+const usage = `usage: snippet [-config file] [-server url] [-token token] command [arguments]
+
+commands:
+  push [file...]  create a snippet from files, or from stdin
+  get id          print the files of a snippet
+  ls              list the public snippets
+  rm id           delete a snippet
+
+Run "snippet command -h" for the flags of a command.
+`
+
+// Define an errUsage error which is returned by the commands when their
+// arguments are wrong, after printing what's wrong, so that Run() exits
+// with status 2 like the flag package does.
+var errUsage = errors.New("usage")
+
+// Define a runner type to hold what the commands need: the API client, the
+// server URL to build the links to snippets, and the standard streams.
+type runner struct {
+	client *client.Client
+	server string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// Run runs the command given by args, without the program name, and returns
+// the exit status. The streams and the environment are passed in rather
+// than taken from the os package so that it can be tested.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer, getenv func(string) string) int {
+	fs := flag.NewFlagSet("snippet", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() { fmt.Fprint(stderr, usage) }
+
+	configPath := fs.String("config", getenv("SNIPPET_CONFIG"), "Path of the config file")
+	server := fs.String("server", "", "URL of the snippetbox server")
+	token := fs.String("token", "", "API token")
+
+	err := fs.Parse(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := loadConfig(*configPath, getenv)
+	if err != nil {
+		fmt.Fprintf(stderr, "snippet: %s\n", err)
+		return 1
+	}
+
+	if *server != "" {
+		cfg.Server = strings.TrimSuffix(*server, "/")
+	}
+	if *token != "" {
+		cfg.Token = *token
+	}
+
+	httpClient, err := cfg.httpClient()
+	if err != nil {
+		fmt.Fprintf(stderr, "snippet: %s\n", err)
+		return 1
+	}
+
+	c := &runner{
+		client: &client.Client{BaseURL: cfg.Server, Token: cfg.Token, HTTPClient: httpClient},
+		server: cfg.Server,
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	commands := map[string]func([]string) error{
+		"push": c.push,
+		"get":  c.get,
+		"ls":   c.ls,
+		"rm":   c.rm,
+	}
+
+	name, args := fs.Arg(0), fs.Args()[1:]
+
+	command, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(stderr, "snippet: unknown command %q\n\n%s", name, usage)
+		return 2
+	}
+
+	err = command(args)
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errUsage):
+		return 2
+	default:
+		fmt.Fprintf(stderr, "snippet %s: %s\n", name, err)
+		return 1
+	}
+}
+
+// The newFlagSet() helper creates the flag set of a command, which prints
+// its errors and usage to stderr.
+func (c *runner) newFlagSet(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(c.stderr, "usage: snippet %s [flags] %s\n", name, arguments)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// The parseArgs() helper parses the flags of a command, which unlike with
+// the flag package can come after its arguments, as in "snippet push
+// main.go -expires 7". Everything after a "--" argument is an argument.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// The usageError() method prints a problem with the arguments of a command
+// along with its usage, and returns errUsage.
+func (c *runner) usageError(fs *flag.FlagSet, format string, a ...any) error {
+	fmt.Fprintf(c.stderr, "snippet %s: %s\n", fs.Name(), fmt.Sprintf(format, a...))
+	fs.Usage()
+	return errUsage
+}
+
+// push: Create a snippet from the given files, or from stdin if there are
+// none, and print its URL
+func (c *runner) push(args []string) error {
+	fs := c.newFlagSet("push", "[file...]")
+	title := fs.String("title", "", "Title of the snippet (default the name of the first file)")
+	expires := fs.String("expires", "", "Days until the snippet expires: 1, 7 or 365, or never or burn (default 365)")
+	visibility := fs.String("visibility", "", "Visibility of the snippet: public, unlisted or private (default public)")
+	tags := fs.String("tags", "", "Comma-separated tags")
+	language := fs.String("language", "", "Language of the files (default guessed from their extension)")
+	name := fs.String("name", "", "File name of the content read from stdin")
+
+	paths, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	snippet := client.NewSnippet{
+		Title:      *title,
+		Visibility: *visibility,
+		Expires:    *expires,
+		Tags:       strings.FieldsFunc(*tags, func(r rune) bool { return r == ',' || r == ' ' }),
+	}
+
+	if len(paths) == 0 {
+		content, err := io.ReadAll(c.stdin)
+		if err != nil {
+			return err
+		}
+
+		snippet.Files = []client.File{{Name: *name, Content: string(content)}}
+	}
+
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		snippet.Files = append(snippet.Files, client.File{Name: filepath.Base(path), Content: string(content)})
+	}
+
+	for i, f := range snippet.Files {
+		switch {
+		case *language != "":
+			snippet.Files[i].Language = *language
+		case f.Name != "":
+			snippet.Files[i].Language = syntax.ForFile(f.Name)
+		}
+	}
+
+	if snippet.Title == "" {
+		snippet.Title = snippet.Files[0].Name
+		if snippet.Title == "" {
+			snippet.Title = "Untitled"
+		}
+	}
+
+	created, err := c.client.Create(snippet)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout, c.server+created.URL)
+	return nil
+}
+
+// get: Print the content of a snippet, identified by its ID or its slug. The
+// files of snippets with several files are each preceded by their name,
+// unless the -file flag picks one of them.
+func (c *runner) get(args []string) error {
+	fs := c.newFlagSet("get", "id")
+	file := fs.String("file", "", "Only print the file with this name")
+
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) != 1 {
+		return c.usageError(fs, "expected one snippet ID or slug")
+	}
+
+	snippet, err := c.client.Get(ids[0])
+	if err != nil {
+		return err
+	}
+
+	files := snippet.Files
+	if *file != "" {
+		files = nil
+		for _, f := range snippet.Files {
+			if f.Name == *file {
+				files = append(files, f)
+			}
+		}
+
+		if len(files) == 0 {
+			return fmt.Errorf("snippet %s has no file named %q", ids[0], *file)
+		}
+	}
+
+	for i, f := range files {
+		if len(files) > 1 {
+			if i > 0 {
+				fmt.Fprintln(c.stdout)
+			}
+			fmt.Fprintf(c.stdout, "==> %s <==\n", f.Name)
+		}
+
+		fmt.Fprint(c.stdout, f.Content)
+		if !strings.HasSuffix(f.Content, "\n") {
+			fmt.Fprintln(c.stdout)
+		}
+	}
+
+	return nil
+}
+
+// ls: Print a page of the public snippets as a table, optionally only those
+// with a tag or matching a search query
+func (c *runner) ls(args []string) error {
+	fs := c.newFlagSet("ls", "")
+	page := fs.Int("page", 1, "Page to list")
+	tag := fs.String("tag", "", "Only list snippets with this tag")
+	query := fs.String("q", "", "Only list snippets matching this search query")
+
+	rest, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(rest) > 0 {
+		return c.usageError(fs, "unexpected argument %q", rest[0])
+	}
+
+	p, err := c.client.List(*page, *tag, *query)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTITLE\tAUTHOR\tCREATED\tEXPIRES")
+
+	for _, s := range p.Snippets {
+		expires := "never"
+		if s.Expires != nil {
+			expires = s.Expires.Local().Format("2006-01-02 15:04")
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", s.ID, s.Title, s.Author, s.Created.Local().Format("2006-01-02 15:04"), expires)
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return err
+	}
+
+	if p.Metadata.LastPage > 1 {
+		fmt.Fprintf(c.stdout, "\nPage %d of %d (%d snippets)\n", p.Metadata.Page, p.Metadata.LastPage, p.Metadata.TotalRecords)
+	}
+
+	return nil
+}
+
+// rm: Delete a snippet, identified by its ID
+func (c *runner) rm(args []string) error {
+	fs := c.newFlagSet("rm", "id")
+
+	ids, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) != 1 {
+		return c.usageError(fs, "expected one snippet ID")
+	}
+
+	id, err := strconv.Atoi(ids[0])
+	if err != nil || id < 1 {
+		return c.usageError(fs, "invalid snippet ID %q", ids[0])
+	}
+
+	err = c.client.Delete(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(c.stdout, "Deleted snippet %d\n", id)
+	return nil
+}
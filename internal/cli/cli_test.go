@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+)
+
+// The commands are tested against the real handlers by the tests of
+// cmd/web. The tests in this file cover what doesn't need a server.
+
+// The runTest helper runs the command with the given arguments and an empty
+// config file, and returns the exit status and what was written to stderr.
+func runTest(t *testing.T, args ...string) (int, string) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte("{}"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+
+	code := Run(append([]string{"-config", path}, args...), strings.NewReader(""), &stdout, &stderr, func(string) string { return "" })
+	return code, stderr.String()
+}
+
+func TestUsageErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStderr string
+	}{
+		{
+			name:       "No command",
+			wantCode:   2,
+			wantStderr: "usage: snippet",
+		},
+		{
+			name:       "Unknown command",
+			args:       []string{"cp", "1"},
+			wantCode:   2,
+			wantStderr: "snippet: unknown command \"cp\"",
+		},
+		{
+			name:       "Missing ID",
+			args:       []string{"get"},
+			wantCode:   2,
+			wantStderr: "snippet get: expected one snippet ID or slug",
+		},
+		{
+			name:       "Invalid ID",
+			args:       []string{"rm", "abc"},
+			wantCode:   2,
+			wantStderr: "snippet rm: invalid snippet ID \"abc\"",
+		},
+		{
+			name:       "Unknown flag",
+			args:       []string{"ls", "-size", "3"},
+			wantCode:   2,
+			wantStderr: "flag provided but not defined: -size",
+		},
+		{
+			name:       "Help",
+			args:       []string{"push", "-h"},
+			wantCode:   0,
+			wantStderr: "usage: snippet push [flags] [file...]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stderr := runTest(t, tt.args...)
+
+			assert.Equal(t, code, tt.wantCode)
+			assert.StringContains(t, stderr, tt.wantStderr)
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	fs := flag.NewFlagSet("push", flag.ContinueOnError)
+	expires := fs.String("expires", "", "")
+
+	args, err := parseArgs(fs, []string{"a.go", "--expires", "7", "b.go", "--", "-c.go"})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, *expires, "7")
+	assert.Equal(t, strings.Join(args, " "), "a.go b.go -c.go")
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{"server": "https://snippetbox.example.com/", "token": "sbx_file"}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noEnv := func(string) string { return "" }
+
+	cfg, err := loadConfig(path, noEnv)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cfg.Server, "https://snippetbox.example.com")
+	assert.Equal(t, cfg.Token, "sbx_file")
+
+	cfg, err = loadConfig(path, func(key string) string {
+		if key == "SNIPPET_TOKEN" {
+			return "sbx_env"
+		}
+		return ""
+	})
+	assert.Equal(t, err, nil)
+	assert.Equal(t, cfg.Server, "https://snippetbox.example.com")
+	assert.Equal(t, cfg.Token, "sbx_env")
+
+	_, err = loadConfig(filepath.Join(t.TempDir(), "missing.json"), noEnv)
+	assert.Equal(t, err != nil, true)
+}
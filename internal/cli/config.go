@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The server used when neither the config file, the environment nor the
+// command line name one, which is where cmd/web listens by default.
+const defaultServer = "https://localhost:4000"
+
+// Define a config type to hold the settings of the command. They are read
+// from a JSON file, such as:
+//
+//	{
+//		"server": "https://snippetbox.example.com",
+//		"token": "sbx_...",
+//		"ca_file": "/path/to/cert.pem"
+//	}
+//
+// CAFile is only needed for servers using a certificate which isn't signed
+// by a trusted authority, like the self-signed one used in development.
+type config struct {
+	Server string `json:"server"`
+	Token  string `json:"token"`
+	CAFile string `json:"ca_file"`
+}
+
+// The defaultConfigPath() helper returns the path of the config file used
+// when none is given, which is snippet/config.json in the user's config
+// directory (such as ~/.config on Linux).
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, "snippet", "config.json"), nil
+}
+
+// The loadConfig() helper reads the config file at path. If path is empty
+// the default config file is read instead, and it's fine for it not to
+// exist. The SNIPPET_SERVER and SNIPPET_TOKEN environment variables, looked
+// up with getenv, take precedence over the file.
+func loadConfig(path string, getenv func(string) string) (config, error) {
+	cfg := config{Server: defaultServer}
+
+	optional := path == ""
+	if optional {
+		var err error
+		path, err = defaultConfigPath()
+		if err != nil {
+			return config{}, err
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && optional:
+	case err != nil:
+		return config{}, err
+	default:
+		err = json.Unmarshal(data, &cfg)
+		if err != nil {
+			return config{}, fmt.Errorf("reading %s: %w", path, err)
+		}
+	}
+
+	if server := getenv("SNIPPET_SERVER"); server != "" {
+		cfg.Server = server
+	}
+
+	if token := getenv("SNIPPET_TOKEN"); token != "" {
+		cfg.Token = token
+	}
+
+	cfg.Server = strings.TrimSuffix(cfg.Server, "/")
+
+	return cfg, nil
+}
+
+// How long a request to the server can take, including reading the
+// response, before giving up.
+const requestTimeout = 30 * time.Second
+
+// The httpClient() method returns the HTTP client used to talk to the
+// server, which also trusts the certificates in CAFile if it is set.
+func (cfg config) httpClient() (*http.Client, error) {
+	if cfg.CAFile == "" {
+		return &http.Client{Timeout: requestTimeout}, nil
+	}
+
+	pem, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+
+	return &http.Client{Transport: transport, Timeout: requestTimeout}, nil
+}
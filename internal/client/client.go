@@ -0,0 +1,222 @@
+// Package client talks to the JSON API of a snippetbox server, which is
+// served under /api/v1/. It is used by the snippet command-line tool.
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Define a Snippet type to hold a snippet as sent by the API. Listings
+// don't include the files or tags of the snippets. Expires is nil for
+// snippets which never expire.
+type Snippet struct {
+	ID               int        `json:"id"`
+	Title            string     `json:"title"`
+	Author           string     `json:"author"`
+	Visibility       string     `json:"visibility"`
+	Slug             string     `json:"slug"`
+	URL              string     `json:"url"`
+	ParentID         int        `json:"parent_id"`
+	Revision         int        `json:"revision"`
+	BurnAfterReading bool       `json:"burn_after_reading"`
+	Stars            int        `json:"stars"`
+	Files            []File     `json:"files"`
+	Tags             []string   `json:"tags"`
+	Created          time.Time  `json:"created"`
+	Expires          *time.Time `json:"expires"`
+}
+
+// Define a File type to hold a file of a snippet.
+type File struct {
+	Name     string `json:"name"`
+	Language string `json:"language"`
+	Content  string `json:"content"`
+}
+
+// Define a NewSnippet type to hold the details of a snippet to create. The
+// server picks defaults for the fields which are left empty: snippets are
+// public and expire in a year. Expires is a number of days, "never" or
+// "burn".
+type NewSnippet struct {
+	Title      string   `json:"title"`
+	Files      []File   `json:"files"`
+	Visibility string   `json:"visibility,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Expires    string   `json:"expires,omitempty"`
+}
+
+// Define a Page type to hold one page of a listing of snippets.
+type Page struct {
+	Snippets []Snippet `json:"snippets"`
+	Metadata struct {
+		Page         int `json:"page"`
+		PageSize     int `json:"page_size"`
+		TotalRecords int `json:"total_records"`
+		LastPage     int `json:"last_page"`
+	} `json:"metadata"`
+}
+
+// Define an Error type for the error responses of the API. Fields holds the
+// validation errors of each field, if the request contained invalid data.
+type Error struct {
+	StatusCode int
+	Message    string            `json:"error"`
+	Fields     map[string]string `json:"fields"`
+	Errors     []string          `json:"errors"`
+}
+
+// Error returns the message of the error, followed by any validation errors
+// on separate lines, in order of field name.
+func (e *Error) Error() string {
+	lines := []string{e.Message}
+
+	for _, field := range slices.Sorted(maps.Keys(e.Fields)) {
+		lines = append(lines, fmt.Sprintf("  %s: %s", field, e.Fields[field]))
+	}
+
+	for _, message := range e.Errors {
+		lines = append(lines, "  "+message)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// Define a Client type which sends requests to the server at BaseURL, such
+// as "https://snippetbox.example.com", authenticated with the API Token if
+// it isn't empty. HTTPClient defaults to http.DefaultClient.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// List returns a page of the public snippets. If tag isn't empty only the
+// snippets with that tag are listed, and if query isn't empty only those
+// matching the search query.
+func (c *Client) List(page int, tag, query string) (Page, error) {
+	params := url.Values{}
+	if page > 1 {
+		params.Set("page", strconv.Itoa(page))
+	}
+	if tag != "" {
+		params.Set("tag", tag)
+	}
+	if query != "" {
+		params.Set("q", query)
+	}
+
+	path := "/api/v1/snippets"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var p Page
+
+	err := c.do(http.MethodGet, path, nil, &p)
+	return p, err
+}
+
+// Get returns a snippet, identified by its ID or by its slug, along with its
+// files.
+func (c *Client) Get(id string) (Snippet, error) {
+	var data struct {
+		Snippet Snippet `json:"snippet"`
+	}
+
+	err := c.do(http.MethodGet, "/api/v1/snippets/"+url.PathEscape(id), nil, &data)
+	return data.Snippet, err
+}
+
+// Create creates a snippet owned by the user of the token, and returns it.
+func (c *Client) Create(snippet NewSnippet) (Snippet, error) {
+	var data struct {
+		Snippet Snippet `json:"snippet"`
+	}
+
+	err := c.do(http.MethodPost, "/api/v1/snippets", snippet, &data)
+	return data.Snippet, err
+}
+
+// Delete deletes a snippet owned by the user of the token.
+func (c *Client) Delete(id int) error {
+	return c.do(http.MethodDelete, "/api/v1/snippets/"+strconv.Itoa(id), nil, nil)
+}
+
+// The do() method sends a request to the API with the given body encoded as
+// JSON, if it isn't nil, and decodes the response into dst, if it isn't nil.
+// Error responses are returned as an *Error.
+func (c *Client) do(method, path string, body any, dst any) error {
+	var r io.Reader
+	if body != nil {
+		js, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(js)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.BaseURL, "/")+path, r)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	rs, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rs.Body.Close()
+
+	if rs.StatusCode >= 400 {
+		apiErr := &Error{StatusCode: rs.StatusCode}
+
+		// Errors which don't come from the API, such as those of a proxy,
+		// aren't JSON, so fall back to the status text.
+		err := json.NewDecoder(rs.Body).Decode(apiErr)
+		if err != nil || apiErr.Message == "" {
+			apiErr.Message = strings.ToLower(http.StatusText(rs.StatusCode))
+		}
+
+		return apiErr
+	}
+
+	if dst == nil {
+		return nil
+	}
+
+	err = json.NewDecoder(rs.Body).Decode(dst)
+	if err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+
+	return nil
+}
+
+// IsNotFound reports whether an error is a 404 Not Found response.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
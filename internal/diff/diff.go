@@ -0,0 +1,272 @@
+// Package diff computes line-based differences between two texts and groups
+// them into hunks, in the style of a unified diff.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind says whether a line is shared by both texts, only present in the new
+// text, or only present in the old text.
+type Kind int
+
+const (
+	Equal Kind = iota
+	Insert
+	Delete
+)
+
+// String returns the name of the kind, which is also used as a CSS class.
+func (k Kind) String() string {
+	switch k {
+	case Insert:
+		return "insert"
+	case Delete:
+		return "delete"
+	default:
+		return "equal"
+	}
+}
+
+// Prefix returns the character which marks lines of this kind in a unified
+// diff.
+func (k Kind) Prefix() string {
+	switch k {
+	case Insert:
+		return "+"
+	case Delete:
+		return "-"
+	default:
+		return " "
+	}
+}
+
+// Line is a single line of a diff.
+type Line struct {
+	Kind Kind
+	Text string
+}
+
+// Hunk is a run of changed lines along with the unchanged lines around them.
+// The start positions are 1-based line numbers in the old and new texts.
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Lines    []Line
+}
+
+// Header returns the "@@ -1,3 +1,4 @@" line which introduces the hunk in a
+// unified diff.
+func (h Hunk) Header() string {
+	return fmt.Sprintf("@@ -%d,%d +%d,%d @@", h.OldStart, h.OldLines, h.NewStart, h.NewLines)
+}
+
+// Unified compares the old and new texts line by line and returns the hunks
+// of a unified diff, keeping up to context unchanged lines around each
+// change. It returns nil if the texts have the same lines.
+func Unified(old, new string, context int) []Hunk {
+	lines := Lines(old, new)
+
+	var hunks []Hunk
+
+	// The position of the next line in each text, counted from 0.
+	oldPos, newPos := 0, 0
+
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == Equal {
+			oldPos++
+			newPos++
+			i++
+			continue
+		}
+
+		// Back up to include the leading context, then extend the hunk until
+		// it is followed by more than twice the context of unchanged lines,
+		// so that nearby changes share a hunk.
+		start := max(i-context, 0)
+		for j := start; j < i; j++ {
+			oldPos--
+			newPos--
+		}
+
+		end := i
+		for end < len(lines) {
+			next := end
+			for next < len(lines) && lines[next].Kind == Equal {
+				next++
+			}
+			if next == len(lines) || next-end > 2*context {
+				end = min(end+context, len(lines))
+				break
+			}
+			for next < len(lines) && lines[next].Kind != Equal {
+				next++
+			}
+			end = next
+		}
+
+		h := Hunk{OldStart: oldPos + 1, NewStart: newPos + 1, Lines: lines[start:end]}
+		for _, l := range h.Lines {
+			if l.Kind != Insert {
+				h.OldLines++
+				oldPos++
+			}
+			if l.Kind != Delete {
+				h.NewLines++
+				newPos++
+			}
+		}
+
+		// By convention an empty range starts at the line before it.
+		if h.OldLines == 0 {
+			h.OldStart--
+		}
+		if h.NewLines == 0 {
+			h.NewStart--
+		}
+
+		hunks = append(hunks, h)
+		i = end
+	}
+
+	return hunks
+}
+
+// Lines compares the old and new texts line by line and returns every line
+// of both, in order, marked as equal, inserted or deleted. It uses Myers'
+// algorithm, so the number of changed lines is as small as possible.
+func Lines(old, new string) []Line {
+	a, b := split(old), split(new)
+
+	// Lines shared at the start and the end don't need to go through the
+	// algorithm, which keeps the common case of a small edit cheap.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	lines := make([]Line, 0, len(a)+len(b))
+	for _, text := range a[:prefix] {
+		lines = append(lines, Line{Kind: Equal, Text: text})
+	}
+
+	lines = append(lines, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, Line{Kind: Equal, Text: text})
+	}
+
+	return lines
+}
+
+// myers() finds a shortest edit script turning a into b. For each number of
+// edits d it records the furthest reaching paths in v, indexed by diagonal
+// k = x - y, and then walks back through the recorded states to recover
+// the path.
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace[d] holds the diagonals -d-1 to d+1 of v as they were before round
+	// d, which is all that the walk back needs.
+	var trace [][]int
+
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				return backtrack(a, b, trace)
+			}
+		}
+	}
+
+	// The loop always finds a path after at most n + m edits.
+	panic("unreachable")
+}
+
+// backtrack() walks back from the end of both texts through the states
+// recorded by myers() and returns the lines of the edit script in order.
+func backtrack(a, b []string, trace [][]int) []Line {
+	x, y := len(a), len(b)
+
+	var lines []Line
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		at := func(k int) int { return v[k+d+1] }
+
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			lines = append(lines, Line{Kind: Equal, Text: a[x]})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				lines = append(lines, Line{Kind: Insert, Text: b[y]})
+			} else {
+				x--
+				lines = append(lines, Line{Kind: Delete, Text: a[x]})
+			}
+		}
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	return lines
+}
+
+// split() breaks a text into lines, ignoring the final line break and
+// treating Windows line endings the same as Unix ones.
+func split(s string) []string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+
+	return strings.Split(s, "\n")
+}
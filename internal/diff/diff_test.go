@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+)
+
+// format() renders hunks as the body of a unified diff, to make the expected
+// results easy to read.
+func format(hunks []Hunk) string {
+	var b strings.Builder
+
+	for _, h := range hunks {
+		b.WriteString(h.Header() + "\n")
+		for _, l := range h.Lines {
+			b.WriteString(l.Kind.Prefix() + l.Text + "\n")
+		}
+	}
+
+	return b.String()
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		context int
+		want    string
+	}{
+		{
+			name:    "Identical",
+			old:     "a\nb\nc\n",
+			new:     "a\nb\nc",
+			context: 3,
+			want:    "",
+		},
+		{
+			name:    "Changed line",
+			old:     "a\nb\nc\n",
+			new:     "a\nB\nc\n",
+			context: 3,
+			want:    "@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:    "Windows line endings",
+			old:     "a\r\nb\r\n",
+			new:     "a\nb\nc\n",
+			context: 3,
+			want:    "@@ -1,2 +1,3 @@\n a\n b\n+c\n",
+		},
+		{
+			name:    "From empty",
+			old:     "",
+			new:     "a\nb",
+			context: 3,
+			want:    "@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:    "To empty",
+			old:     "a\nb",
+			new:     "",
+			context: 3,
+			want:    "@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:    "Separate hunks",
+			old:     "1\n2\n3\n4\n5\n6\n7\n8\n9",
+			new:     "one\n2\n3\n4\n5\n6\n7\n8\nnine",
+			context: 1,
+			want:    "@@ -1,2 +1,2 @@\n-1\n+one\n 2\n@@ -8,2 +8,2 @@\n 8\n-9\n+nine\n",
+		},
+		{
+			name:    "Nearby changes share a hunk",
+			old:     "1\n2\n3\n4\n5",
+			new:     "one\n2\n3\n4\nfive",
+			context: 2,
+			want:    "@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+		},
+		{
+			name:    "Interleaved changes",
+			old:     "a\nb\nc\na\nb\nb\na",
+			new:     "c\nb\na\nb\na\nc",
+			context: 0,
+			want:    "@@ -1,2 +0,0 @@\n-a\n-b\n@@ -3,0 +2,1 @@\n+b\n@@ -6,1 +4,0 @@\n-b\n@@ -7,0 +6,1 @@\n+c\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, format(Unified(tt.old, tt.new, tt.context)), tt.want)
+		})
+	}
+}
+
+func TestLines(t *testing.T) {
+	old := "a\nb\nc\na\nb\nb\na"
+	new := "c\nb\na\nb\na\nc"
+
+	lines := Lines(old, new)
+
+	// Rebuild both texts from the edit script to check that it is complete,
+	// and count the changes to check that it is as short as possible.
+	var gotOld, gotNew []string
+	changes := 0
+
+	for _, l := range lines {
+		if l.Kind != Insert {
+			gotOld = append(gotOld, l.Text)
+		}
+		if l.Kind != Delete {
+			gotNew = append(gotNew, l.Text)
+		}
+		if l.Kind != Equal {
+			changes++
+		}
+	}
+
+	assert.Equal(t, strings.Join(gotOld, "\n"), old)
+	assert.Equal(t, strings.Join(gotNew, "\n"), new)
+	assert.Equal(t, changes, 5)
+}
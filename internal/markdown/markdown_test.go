@@ -0,0 +1,86 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		want     string
+		dontWant string
+	}{
+		{
+			name:   "Heading",
+			source: "# Deploying",
+			want:   "<h1>Deploying</h1>",
+		},
+		{
+			name:   "Link",
+			source: "[docs](https://example.com)",
+			want:   `<a href="https://example.com">docs</a>`,
+		},
+		{
+			name:     "Raw HTML is left out",
+			source:   "<script>alert(1)</script>",
+			dontWant: "<script>",
+		},
+		{
+			name:     "Inline HTML is left out",
+			source:   "Click <a href='#' onclick='alert(1)'>here</a>",
+			dontWant: "onclick",
+		},
+		{
+			name:     "Dangerous links are neutralised",
+			source:   "[click](javascript:alert(1))",
+			dontWant: "javascript:",
+		},
+		{
+			name:   "Fenced code is highlighted",
+			source: "```go\nfunc main() {}\n```",
+			want:   `<pre class="chroma"><code><span class="kd">func</span>`,
+		},
+		{
+			name:   "Fenced code is escaped",
+			source: "```\n<b>bold</b>\n```",
+			want:   "&lt;b&gt;bold&lt;/b&gt;",
+		},
+		{
+			name:   "Unknown fence language",
+			source: "```klingon\n<b>Qapla'</b>\n```",
+			want:   "&lt;b&gt;Qapla&#39;&lt;/b&gt;",
+		},
+		{
+			name:     "Table alignment avoids inline styles",
+			source:   "| a | b |\n|:--|--:|\n| 1 | 2 |",
+			want:     `<th align="left">a</th>`,
+			dontWant: "style=",
+		},
+		{
+			name:   "Task list",
+			source: "- [x] Back up the database",
+			want:   `<input checked="" disabled="" type="checkbox"> Back up the database`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML(tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.want != "" {
+				assert.StringContains(t, string(got), tt.want)
+			}
+
+			if tt.dontWant != "" {
+				assert.Equal(t, strings.Contains(string(got), tt.dontWant), false)
+			}
+		})
+	}
+}
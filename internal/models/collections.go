@@ -0,0 +1,257 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type CollectionModelInterface interface {
+	Insert(userID int, slug, title, description, visibility string) (int, error)
+	Get(slug string) (Collection, error)
+	Update(id int, slug, title, description, visibility string, snippetIDs []int) error
+	AddSnippet(id int, snippetID int) error
+	ListByUser(userID int) ([]Collection, error)
+	ForSnippet(snippetID int) ([]Collection, error)
+}
+
+// Define a Collection type which holds a named, ordered list of snippets
+// curated by a user. Collections are reached through their Slug, and their
+// Visibility works like that of snippets, except that unlisted and public
+// collections can both be viewed by anyone with the link; only public ones
+// are shown on the pages of their snippets. Snippets holds the snippets of
+// the collection in order, and is only filled in by Get.
+type Collection struct {
+	ID          int
+	UserID      int
+	UserName    string
+	Slug        string
+	Title       string
+	Description string
+	Visibility  string
+	Created     time.Time
+	Snippets    []Snippet
+}
+
+// Define a CollectionModel type which wraps a sql.DB connection pool
+type CollectionModel struct {
+	DB *sql.DB
+}
+
+// The selectCollection query fetches every column of a collection, along
+// with the name of the user who created it.
+const selectCollection = `SELECT c.id, c.user_id, u.name, c.slug, c.title, c.description, c.visibility, c.created
+	FROM collections c INNER JOIN users u ON u.id = c.user_id`
+
+// The scanCollection() helper reads a row returned by selectCollection.
+func scanCollection(row interface{ Scan(dest ...any) error }) (Collection, error) {
+	var c Collection
+
+	err := row.Scan(&c.ID, &c.UserID, &c.UserName, &c.Slug, &c.Title, &c.Description, &c.Visibility, &c.Created)
+
+	return c, err
+}
+
+// Insert adds an empty collection owned by the given user, and returns its
+// ID. If the slug is already taken ErrDuplicateSlug is returned.
+func (m *CollectionModel) Insert(userID int, slug, title, description, visibility string) (int, error) {
+	stmt := `INSERT INTO collections (user_id, slug, title, description, visibility, created)
+	VALUES(?, ?, ?, ?, ?, UTC_TIMESTAMP())`
+
+	result, err := m.DB.Exec(stmt, userID, slug, title, description, visibility)
+	if err != nil {
+		return 0, duplicateSlug(err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// The duplicateSlug() helper turns the MySQL error for a slug which is
+// already taken into ErrDuplicateSlug, and returns other errors unchanged.
+func duplicateSlug(err error) error {
+	var mySQLError *mysql.MySQLError
+	if errors.As(err, &mySQLError) {
+		if mySQLError.Number == 1062 && strings.Contains(mySQLError.Message, "collections_uc_slug") {
+			return ErrDuplicateSlug
+		}
+	}
+
+	return err
+}
+
+// Get returns the collection with the given slug along with its snippets,
+// or ErrNoRecord if there is none. Snippets which have expired, or can't
+// be shared any more because they were made private or burn after reading,
+// are left out.
+func (m *CollectionModel) Get(slug string) (Collection, error) {
+	c, err := scanCollection(m.DB.QueryRow(selectCollection+` WHERE c.slug = ?`, slug))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Collection{}, ErrNoRecord
+		} else {
+			return Collection{}, err
+		}
+	}
+
+	// Public collections are listed, so they leave out unlisted snippets,
+	// whose slugs would be given away otherwise. A snippet might have been
+	// made unlisted since it was added.
+	stmt := `SELECT ` + listColumns + ` FROM snippets s
+	INNER JOIN collection_snippets cs ON cs.snippet_id = s.id
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility <> 'private' AND NOT s.burn_after_reading
+	AND (s.visibility <> 'unlisted' OR ? <> 'public')
+	AND cs.collection_id = ?
+	ORDER BY cs.position`
+
+	rows, err := m.DB.Query(stmt, c.Visibility, c.ID)
+	if err != nil {
+		return Collection{}, err
+	}
+
+	c.Snippets, err = scanSnippets(rows)
+	if err != nil {
+		return Collection{}, err
+	}
+
+	return c, nil
+}
+
+// Update changes the details of a collection, and replaces its snippets
+// with the given ones, in order. Snippets which aren't already in the
+// collection are ignored, so this can only remove and reorder snippets;
+// they are added with AddSnippet(). If the slug is already taken
+// ErrDuplicateSlug is returned.
+func (m *CollectionModel) Update(id int, slug, title, description, visibility string, snippetIDs []int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback() is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	stmt := `UPDATE collections SET slug = ?, title = ?, description = ?, visibility = ?
+	WHERE id = ?`
+
+	result, err := tx.Exec(stmt, slug, title, description, visibility, id)
+	if err != nil {
+		return duplicateSlug(err)
+	}
+
+	// MySQL doesn't count rows which didn't change, so check that the
+	// collection exists separately.
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		var exists bool
+
+		err = tx.QueryRow("SELECT EXISTS(SELECT true FROM collections WHERE id = ?)", id).Scan(&exists)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return ErrNoRecord
+		}
+	}
+
+	// Remove the snippets which aren't in the list any more, then number
+	// the remaining ones in the order given.
+	stmt = "DELETE FROM collection_snippets WHERE collection_id = ?"
+	args := []any{id}
+
+	if len(snippetIDs) > 0 {
+		placeholders := make([]string, len(snippetIDs))
+		for i, snippetID := range snippetIDs {
+			placeholders[i] = "?"
+			args = append(args, snippetID)
+		}
+		stmt += " AND snippet_id NOT IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	_, err = tx.Exec(stmt, args...)
+	if err != nil {
+		return err
+	}
+
+	for i, snippetID := range snippetIDs {
+		stmt := "UPDATE collection_snippets SET position = ? WHERE collection_id = ? AND snippet_id = ?"
+
+		_, err = tx.Exec(stmt, i, id, snippetID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AddSnippet appends a snippet to the end of a collection. Adding a snippet
+// which is already in the collection has no effect.
+func (m *CollectionModel) AddSnippet(id int, snippetID int) error {
+	stmt := `INSERT IGNORE INTO collection_snippets (collection_id, snippet_id, position)
+	SELECT ?, ?, COALESCE(MAX(position) + 1, 0) FROM collection_snippets WHERE collection_id = ?`
+
+	_, err := m.DB.Exec(stmt, id, snippetID, id)
+	return err
+}
+
+// ListByUser returns every collection created by a user, in alphabetical
+// order of title.
+func (m *CollectionModel) ListByUser(userID int) ([]Collection, error) {
+	rows, err := m.DB.Query(selectCollection+` WHERE c.user_id = ? ORDER BY c.title, c.id`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCollections(rows)
+}
+
+// ForSnippet returns the public collections which contain a snippet, in
+// alphabetical order of title.
+func (m *CollectionModel) ForSnippet(snippetID int) ([]Collection, error) {
+	stmt := selectCollection + `
+	INNER JOIN collection_snippets cs ON cs.collection_id = c.id
+	WHERE cs.snippet_id = ? AND c.visibility = 'public'
+	ORDER BY c.title, c.id`
+
+	rows, err := m.DB.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanCollections(rows)
+}
+
+// The scanCollections() helper reads every row returned by a query based on
+// selectCollection, then closes the rows.
+func scanCollections(rows *sql.Rows) ([]Collection, error) {
+	defer rows.Close()
+
+	var collections []Collection
+
+	for rows.Next() {
+		c, err := scanCollection(rows)
+		if err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return collections, nil
+}
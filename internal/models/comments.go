@@ -0,0 +1,148 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type CommentModelInterface interface {
+	Insert(snippetID int, userID int, line LineRef, content string) (int, error)
+	Get(id int) (Comment, error)
+	ForSnippet(snippetID int) ([]Comment, error)
+	Delete(id int) error
+}
+
+// Define a Comment type which holds a comment left on a snippet. The UserID
+// and UserName fields identify the user who wrote it. Line comments also
+// say which line they are about; for general comments Line is the zero
+// LineRef.
+type Comment struct {
+	ID        int
+	SnippetID int
+	UserID    int
+	UserName  string
+	Line      LineRef
+	Content   string
+	Created   time.Time
+}
+
+// Define a LineRef type which points at a line of a file in a given revision
+// of a snippet. Lines are numbered from 1.
+type LineRef struct {
+	Revision int
+	FileName string
+	Number   int
+}
+
+// IsZero reports whether the LineRef doesn't point at any line, as is the
+// case for general comments.
+func (l LineRef) IsZero() bool {
+	return l.Number == 0
+}
+
+// Define a CommentModel type which wraps a sql.DB connection pool
+type CommentModel struct {
+	DB *sql.DB
+}
+
+// The selectComment query fetches every column of a comment, along with the
+// name of the user who wrote it.
+const selectComment = `SELECT c.id, c.snippet_id, c.user_id, u.name,
+	COALESCE(c.revision, 0), COALESCE(c.file_name, ''), COALESCE(c.line, 0), c.content, c.created
+	FROM comments c INNER JOIN users u ON u.id = c.user_id`
+
+// The scanComment() helper reads a row returned by selectComment.
+func scanComment(row interface{ Scan(dest ...any) error }) (Comment, error) {
+	var c Comment
+
+	err := row.Scan(&c.ID, &c.SnippetID, &c.UserID, &c.UserName,
+		&c.Line.Revision, &c.Line.FileName, &c.Line.Number, &c.Content, &c.Created)
+
+	return c, err
+}
+
+// Insert adds a comment by the given user to a snippet, and returns its ID.
+// A zero line makes it a general comment.
+func (m *CommentModel) Insert(snippetID int, userID int, line LineRef, content string) (int, error) {
+	stmt := `INSERT INTO comments (snippet_id, user_id, revision, file_name, line, content, created)
+	VALUES(?, ?, ?, ?, ?, ?, UTC_TIMESTAMP())`
+
+	// General comments store NULL rather than a made up line.
+	var revision, fileName, number any
+	if !line.IsZero() {
+		revision, fileName, number = line.Revision, line.FileName, line.Number
+	}
+
+	result, err := m.DB.Exec(stmt, snippetID, userID, revision, fileName, number, content)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// Get returns a specific comment, or ErrNoRecord if there is no comment with
+// that ID.
+func (m *CommentModel) Get(id int) (Comment, error) {
+	c, err := scanComment(m.DB.QueryRow(selectComment+` WHERE c.id = ?`, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Comment{}, ErrNoRecord
+		} else {
+			return Comment{}, err
+		}
+	}
+
+	return c, nil
+}
+
+// ForSnippet returns the comments on a snippet, oldest first.
+func (m *CommentModel) ForSnippet(snippetID int) ([]Comment, error) {
+	rows, err := m.DB.Query(selectComment+` WHERE c.snippet_id = ? ORDER BY c.id`, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []Comment
+
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// Delete removes a comment, returning ErrNoRecord if there is no comment
+// with that ID.
+func (m *CommentModel) Delete(id int) error {
+	result, err := m.DB.Exec("DELETE FROM comments WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
@@ -10,4 +10,7 @@ var (
 
 	// If the user tries to signup with an email address that's already in use
 	ErrDuplicateEmail = errors.New("models: duplicate email")
+
+	// If the user tries to give a collection a slug that's already taken
+	ErrDuplicateSlug = errors.New("models: duplicate slug")
 )
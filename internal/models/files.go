@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// Define a File type to hold one of the named files of a snippet. Files are
+// kept in the order in which they were entered, and each has its own
+// language. The files of every revision of a snippet are stored in the
+// "snippet_files" table.
+type File struct {
+	Name     string
+	Language string
+	Content  string
+}
+
+// The queryer interface is satisfied by both *sql.DB and *sql.Tx, so that
+// files can be read inside or outside of a transaction.
+type queryer interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// The insertFiles() helper stores the files of a revision of a snippet, in
+// order.
+func insertFiles(tx *sql.Tx, snippetID int, revision int, files []File) error {
+	stmt := `INSERT INTO snippet_files (snippet_id, revision, position, name, language, content)
+	VALUES(?, ?, ?, ?, ?, ?)`
+
+	for i, f := range files {
+		_, err := tx.Exec(stmt, snippetID, revision, i, f.Name, f.Language, f.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// The selectFiles() helper returns the files of a revision of a snippet, in
+// order.
+func selectFiles(q queryer, snippetID int, revision int) ([]File, error) {
+	stmt := `SELECT name, language, content FROM snippet_files
+	WHERE snippet_id = ? AND revision = ? ORDER BY position`
+
+	rows, err := q.Query(stmt, snippetID, revision)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []File
+
+	for rows.Next() {
+		var f File
+
+		err = rows.Scan(&f.Name, &f.Language, &f.Content)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// The attachFiles() helper fills in the files of the current revision of
+// each of the given snippets, using a single query.
+func attachFiles(q queryer, snippets []Snippet) error {
+	if len(snippets) == 0 {
+		return nil
+	}
+
+	// Build one placeholder per snippet for the IN clause, and remember
+	// where each snippet is in the slice.
+	placeholders := make([]string, len(snippets))
+	args := make([]any, len(snippets))
+	index := make(map[int]int, len(snippets))
+
+	for i, s := range snippets {
+		placeholders[i] = "?"
+		args[i] = s.ID
+		index[s.ID] = i
+	}
+
+	stmt := `SELECT f.snippet_id, f.name, f.language, f.content
+	FROM snippet_files f INNER JOIN snippets s ON s.id = f.snippet_id AND s.revision = f.revision
+	WHERE f.snippet_id IN (` + strings.Join(placeholders, ", ") + `)
+	ORDER BY f.snippet_id, f.position`
+
+	rows, err := q.Query(stmt, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var snippetID int
+		var f File
+
+		err = rows.Scan(&snippetID, &f.Name, &f.Language, &f.Content)
+		if err != nil {
+			return err
+		}
+
+		i := index[snippetID]
+		snippets[i].Files = append(snippets[i].Files, f)
+	}
+
+	return rows.Err()
+}
+
+// LineCount returns the number of lines in the file. A final line break
+// doesn't start another line, and Windows line endings count as one break.
+func (f File) LineCount() int {
+	s := strings.ReplaceAll(f.Content, "\r\n", "\n")
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return 0
+	}
+
+	return strings.Count(s, "\n") + 1
+}
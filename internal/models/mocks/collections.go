@@ -0,0 +1,109 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/Overlrd/snippetbox/internal/models"
+)
+
+var mockCollection = models.Collection{
+	ID:          1,
+	UserID:      1,
+	UserName:    "Alice",
+	Slug:        "onboarding",
+	Title:       "Onboarding",
+	Description: "Everything a new starter needs.",
+	Visibility:  models.VisibilityPublic,
+	Created:     time.Now(),
+	Snippets:    []models.Snippet{mockSnippet, mockMarkdownSnippet},
+}
+
+var mockPrivateCollection = models.Collection{
+	ID:          2,
+	UserID:      1,
+	UserName:    "Alice",
+	Slug:        "drafts",
+	Title:       "Drafts",
+	Description: "",
+	Visibility:  models.VisibilityPrivate,
+	Created:     time.Now(),
+}
+
+var mockUnlistedCollection = models.Collection{
+	ID:          3,
+	UserID:      1,
+	UserName:    "Alice",
+	Slug:        "dW5saXN0ZWQtY29sbGVjdGlvbg",
+	Title:       "Secrets",
+	Description: "",
+	Visibility:  models.VisibilityUnlisted,
+	Created:     time.Now(),
+	Snippets:    []models.Snippet{mockUnlistedSnippet},
+}
+
+var mockCollections = []models.Collection{mockCollection, mockPrivateCollection, mockUnlistedCollection}
+
+type CollectionModel struct{}
+
+func (m *CollectionModel) Insert(userID int, slug, title, description, visibility string) (int, error) {
+	for _, c := range mockCollections {
+		if c.Slug == slug {
+			return 0, models.ErrDuplicateSlug
+		}
+	}
+
+	return 4, nil
+}
+
+func (m *CollectionModel) Get(slug string) (models.Collection, error) {
+	for _, c := range mockCollections {
+		if c.Slug == slug {
+			return c, nil
+		}
+	}
+
+	return models.Collection{}, models.ErrNoRecord
+}
+
+func (m *CollectionModel) Update(id int, slug, title, description, visibility string, snippetIDs []int) error {
+	for _, c := range mockCollections {
+		if c.Slug == slug && c.ID != id {
+			return models.ErrDuplicateSlug
+		}
+	}
+
+	switch id {
+	case 1, 2, 3:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *CollectionModel) AddSnippet(id int, snippetID int) error {
+	return nil
+}
+
+func (m *CollectionModel) ListByUser(userID int) ([]models.Collection, error) {
+	var collections []models.Collection
+
+	for _, c := range mockCollections {
+		if c.UserID == userID {
+			c.Snippets = nil
+			collections = append(collections, c)
+		}
+	}
+
+	return collections, nil
+}
+
+func (m *CollectionModel) ForSnippet(snippetID int) ([]models.Collection, error) {
+	switch snippetID {
+	case 1:
+		c := mockCollection
+		c.Snippets = nil
+		return []models.Collection{c}, nil
+	default:
+		return nil, nil
+	}
+}
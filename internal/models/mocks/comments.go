@@ -0,0 +1,72 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/Overlrd/snippetbox/internal/models"
+)
+
+var mockComment = models.Comment{
+	ID:        1,
+	SnippetID: 1,
+	UserID:    2,
+	UserName:  "Bob",
+	Content:   "What a lovely haiku.",
+	Created:   time.Now(),
+}
+
+// A comment on the second line of a file of the current revision of
+// mockSnippet.
+var mockLineComment = models.Comment{
+	ID:        3,
+	SnippetID: 1,
+	UserID:    1,
+	UserName:  "Alice",
+	Line:      models.LineRef{Revision: 2, FileName: "frog.txt", Number: 2},
+	Content:   "Is the splash too loud?",
+	Created:   time.Now(),
+}
+
+// A comment on a line of an earlier revision of mockSnippet.
+var mockOutdatedComment = models.Comment{
+	ID:        4,
+	SnippetID: 1,
+	UserID:    2,
+	UserName:  "Bob",
+	Line:      models.LineRef{Revision: 1, FileName: "pond.txt", Number: 1},
+	Content:   "Which pond is this?",
+	Created:   time.Now(),
+}
+
+type CommentModel struct{}
+
+func (m *CommentModel) Insert(snippetID int, userID int, line models.LineRef, content string) (int, error) {
+	return 2, nil
+}
+
+func (m *CommentModel) Get(id int) (models.Comment, error) {
+	switch id {
+	case 1:
+		return mockComment, nil
+	default:
+		return models.Comment{}, models.ErrNoRecord
+	}
+}
+
+func (m *CommentModel) ForSnippet(snippetID int) ([]models.Comment, error) {
+	switch snippetID {
+	case 1:
+		return []models.Comment{mockComment, mockLineComment, mockOutdatedComment}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (m *CommentModel) Delete(id int) error {
+	switch id {
+	case 1:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
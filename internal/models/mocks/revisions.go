@@ -0,0 +1,54 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/Overlrd/snippetbox/internal/models"
+)
+
+var mockRevisions = []models.Revision{
+	{
+		SnippetID: 1,
+		Number:    2,
+		UserID:    1,
+		UserName:  "Alice",
+		Title:     "An old silent pond",
+		Files:     mockSnippet.Files,
+		Created:   time.Now(),
+	},
+	{
+		SnippetID: 1,
+		Number:    1,
+		UserID:    1,
+		UserName:  "Alice",
+		Title:     "An old pond",
+		Files: []models.File{
+			{Name: "pond.txt", Language: "text", Content: "An old pond..."},
+		},
+		Created: time.Now(),
+	},
+}
+
+type RevisionModel struct{}
+
+func (m *RevisionModel) List(snippetID int) ([]models.Revision, error) {
+	var revisions []models.Revision
+
+	for _, r := range mockRevisions {
+		if r.SnippetID == snippetID {
+			revisions = append(revisions, r)
+		}
+	}
+
+	return revisions, nil
+}
+
+func (m *RevisionModel) Get(snippetID int, number int) (models.Revision, error) {
+	for _, r := range mockRevisions {
+		if r.SnippetID == snippetID && r.Number == number {
+			return r, nil
+		}
+	}
+
+	return models.Revision{}, models.ErrNoRecord
+}
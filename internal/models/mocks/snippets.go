@@ -1,33 +1,217 @@
 package mocks
 
 import (
-	"github.com/Overlrd/snippetbox/internal/models"
+	"strings"
 	"time"
+
+	"github.com/Overlrd/snippetbox/internal/models"
 )
 
 var mockSnippet = models.Snippet{
-	ID:      1,
-	Title:   "An old silent pond",
-	Content: "An old silent pond...",
-	Created: time.Now(),
-	Expires: time.Now(),
+	ID:       1,
+	UserID:   1,
+	UserName: "Alice",
+	Title:    "An old silent pond",
+	Files: []models.File{
+		{Name: "pond.txt", Language: "text", Content: "An old silent pond..."},
+		{Name: "frog.txt", Language: "text", Content: "A frog jumps into the pond,\nsplash! Silence again."},
+	},
+	Visibility: models.VisibilityPublic,
+	Slug:       "cHVibGljLXNuaXBwZXQtMQ",
+	Forks:      1,
+	Stars:      1,
+	Views:      42,
+	Revision:   2,
+	Created:    time.Now(),
+	Expires:    time.Now(),
+}
+
+var mockUnlistedSnippet = models.Snippet{
+	ID:       3,
+	UserID:   1,
+	UserName: "Alice",
+	ParentID: 1,
+	Title:    "Over the wintry forest",
+	Files: []models.File{
+		{Name: "forest.txt", Language: "text", Content: "Over the wintry forest, winds howl in rage..."},
+	},
+	Visibility: models.VisibilityUnlisted,
+	Slug:       "dW5saXN0ZWQtc25pcHBldDM",
+	Revision:   1,
+	Created:    time.Now(),
+	Expires:    time.Now(),
+}
+
+var mockPrivateSnippet = models.Snippet{
+	ID:       4,
+	UserID:   1,
+	UserName: "Alice",
+	Title:    "First autumn morning",
+	Files: []models.File{
+		{Name: "autumn.txt", Language: "text", Content: "First autumn morning, the mirror I stare into..."},
+	},
+	Visibility: models.VisibilityPrivate,
+	Slug:       "cHJpdmF0ZS1zbmlwcGV0LTQ",
+	Revision:   1,
+	Created:    time.Now(),
+	Expires:    time.Now(),
+}
+
+var mockBurnSnippet = models.Snippet{
+	ID:       5,
+	UserID:   1,
+	UserName: "Alice",
+	Title:    "Database password",
+	Files: []models.File{
+		{Name: "password.txt", Language: "text", Content: "correct horse battery staple"},
+	},
+	Visibility:       models.VisibilityUnlisted,
+	Slug:             "YnVybi1zbmlwcGV0LWZpdmU",
+	BurnAfterReading: true,
+	Revision:         1,
+	Created:          time.Now(),
+	Expires:          time.Now(),
+}
+
+var mockMarkdownSnippet = models.Snippet{
+	ID:       6,
+	UserID:   1,
+	UserName: "Alice",
+	Title:    "Deploying",
+	Files: []models.File{
+		{Name: "deploy.md", Language: "markdown", Content: "# Deploying\n\nRun <script>alert(1)</script> this:\n\n```go\nfunc deploy() {}\n```\n"},
+	},
+	Visibility: models.VisibilityPublic,
+	Slug:       "bWFya2Rvd24tc25pcHBldDY",
+	Revision:   1,
+	Created:    time.Now(),
+	Expires:    time.Now(),
 }
 
+var mockSnippets = []models.Snippet{mockSnippet, mockUnlistedSnippet, mockPrivateSnippet, mockBurnSnippet, mockMarkdownSnippet}
+
 type SnippetModel struct{}
 
-func (m *SnippetModel) Insert(title string, content string, expires int) (int, error) {
+func (m *SnippetModel) Insert(userID int, title string, files []models.File, visibility string, expires time.Time, burn bool) (int, error) {
 	return 2, nil
 }
 
 func (m *SnippetModel) Get(id int) (models.Snippet, error) {
+	for _, s := range mockSnippets {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+
+	return models.Snippet{}, models.ErrNoRecord
+}
+
+func (m *SnippetModel) GetBySlug(slug string) (models.Snippet, error) {
+	for _, s := range mockSnippets {
+		if s.Slug == slug {
+			return s, nil
+		}
+	}
+
+	return models.Snippet{}, models.ErrNoRecord
+}
+
+func (m *SnippetModel) Update(id int, userID int, title string, files []models.File, visibility string, expires time.Time, burn bool) error {
 	switch id {
 	case 1:
-		return mockSnippet, nil
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *SnippetModel) Delete(id int) error {
+	switch id {
+	case 1:
+		return nil
+	default:
+		return models.ErrNoRecord
+	}
+}
+
+func (m *SnippetModel) DeleteExpired(limit int) (int, error) {
+	return 0, nil
+}
+
+func (m *SnippetModel) Fork(id int, userID int) (int, error) {
+	switch id {
+	case 1, 3:
+		return 2, nil
+	default:
+		return 0, models.ErrNoRecord
+	}
+}
+
+func (m *SnippetModel) Burn(id int) (models.Snippet, error) {
+	switch id {
+	case 5:
+		return mockBurnSnippet, nil
 	default:
 		return models.Snippet{}, models.ErrNoRecord
 	}
 }
 
-func (m *SnippetModel) Latest() ([]models.Snippet, error) {
-	return []models.Snippet{mockSnippet}, nil
+// The listed() helper keeps only the fields of a snippet which the listing
+// queries of the real model fill in.
+func listed(s models.Snippet) models.Snippet {
+	return models.Snippet{
+		ID:         s.ID,
+		Title:      s.Title,
+		UserName:   s.UserName,
+		Visibility: s.Visibility,
+		Slug:       s.Slug,
+		Stars:      s.Stars,
+		Created:    s.Created,
+		Expires:    s.Expires,
+	}
+}
+
+func (m *SnippetModel) List(page int, pageSize int) ([]models.Snippet, int, error) {
+	if page > 1 {
+		return nil, 1, nil
+	}
+
+	return []models.Snippet{listed(mockSnippet)}, 1, nil
+}
+
+func (m *SnippetModel) Search(query string, page int, pageSize int) ([]models.Snippet, int, error) {
+	if !strings.Contains(strings.ToLower(mockSnippet.Content()), strings.ToLower(query)) {
+		return nil, 0, nil
+	}
+
+	if page > 1 {
+		return nil, 1, nil
+	}
+
+	return []models.Snippet{listed(mockSnippet)}, 1, nil
+}
+
+func (m *SnippetModel) ListByTag(tag string, page int, pageSize int) ([]models.Snippet, int, error) {
+	if tag != "haiku" && tag != "poetry" {
+		return nil, 0, nil
+	}
+
+	if page > 1 {
+		return nil, 1, nil
+	}
+
+	return []models.Snippet{listed(mockSnippet)}, 1, nil
+}
+
+func (m *SnippetModel) ListStarred(userID int, page int, pageSize int) ([]models.Snippet, int, error) {
+	// Bob starred the first snippet.
+	if userID != 2 {
+		return nil, 0, nil
+	}
+
+	if page > 1 {
+		return nil, 1, nil
+	}
+
+	return []models.Snippet{listed(mockSnippet)}, 1, nil
 }
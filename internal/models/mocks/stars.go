@@ -0,0 +1,16 @@
+package mocks
+
+type StarModel struct{}
+
+func (m *StarModel) Insert(userID int, snippetID int) error {
+	return nil
+}
+
+func (m *StarModel) Delete(userID int, snippetID int) error {
+	return nil
+}
+
+func (m *StarModel) Exists(userID int, snippetID int) (bool, error) {
+	// Bob starred the first snippet.
+	return userID == 2 && snippetID == 1, nil
+}
@@ -0,0 +1,24 @@
+package mocks
+
+import (
+	"github.com/Overlrd/snippetbox/internal/models"
+)
+
+type TagModel struct{}
+
+func (m *TagModel) Set(snippetID int, names []string) error {
+	return nil
+}
+
+func (m *TagModel) ForSnippet(snippetID int) ([]string, error) {
+	switch snippetID {
+	case 1:
+		return []string{"haiku", "poetry"}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (m *TagModel) Cloud(limit int) ([]models.Tag, error) {
+	return []models.Tag{{Name: "haiku", Count: 1}, {Name: "poetry", Count: 1}}, nil
+}
@@ -0,0 +1,54 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/Overlrd/snippetbox/internal/models"
+)
+
+// The API tokens of the mock users, which can be used in Authorization
+// headers.
+var mockTokens = map[string]int{
+	"sbx_alice": 1,
+	"sbx_bob":   2,
+	"sbx_carol": 3,
+}
+
+var mockToken = models.Token{
+	ID:       1,
+	UserID:   1,
+	Name:     "Deploy script",
+	Created:  time.Now(),
+	LastUsed: time.Now(),
+}
+
+type TokenModel struct{}
+
+func (m *TokenModel) Insert(userID int, name string, expires time.Time) (string, error) {
+	return "sbx_bmV3LXRva2Vu", nil
+}
+
+func (m *TokenModel) Authenticate(token string) (int, error) {
+	userID, ok := mockTokens[token]
+	if !ok {
+		return 0, models.ErrInvalidCredentials
+	}
+
+	return userID, nil
+}
+
+func (m *TokenModel) ListByUser(userID int) ([]models.Token, error) {
+	if userID != 1 {
+		return nil, nil
+	}
+
+	return []models.Token{mockToken}, nil
+}
+
+func (m *TokenModel) Delete(id int, userID int) error {
+	if id != 1 || userID != 1 {
+		return models.ErrNoRecord
+	}
+
+	return nil
+}
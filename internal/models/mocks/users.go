@@ -1,9 +1,17 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/Overlrd/snippetbox/internal/models"
 )
 
+var mockUsers = map[int]models.User{
+	1: {ID: 1, Name: "Alice", Email: "alice@example.com", Created: time.Now()},
+	2: {ID: 2, Name: "Bob", Email: "bob@example.com", Created: time.Now()},
+	3: {ID: 3, Name: "Carol", Email: "carol@example.com", Created: time.Now(), IsAdmin: true},
+}
+
 type UserModel struct{}
 
 func (m *UserModel) Insert(name, email, password string) error {
@@ -16,18 +24,25 @@ func (m *UserModel) Insert(name, email, password string) error {
 }
 
 func (m *UserModel) Authenticate(email, password string) (int, error) {
-	if email == "alice@example.com" && password == "password" {
-		return 1, nil
+	for _, user := range mockUsers {
+		if email == user.Email && password == "password" {
+			return user.ID, nil
+		}
 	}
 
 	return 0, models.ErrInvalidCredentials
 }
 
 func (m *UserModel) Exists(id int) (bool, error) {
-	switch id {
-	case 1:
-		return true, nil
-	default:
-		return false, nil
+	_, ok := mockUsers[id]
+	return ok, nil
+}
+
+func (m *UserModel) Get(id int) (models.User, error) {
+	user, ok := mockUsers[id]
+	if !ok {
+		return models.User{}, models.ErrNoRecord
 	}
+
+	return user, nil
 }
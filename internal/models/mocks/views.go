@@ -0,0 +1,20 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/Overlrd/snippetbox/internal/models"
+)
+
+type ViewModel struct{}
+
+func (m *ViewModel) Add(counts map[int]int) error {
+	return nil
+}
+
+func (m *ViewModel) MostViewed(since time.Time, limit int) ([]models.Snippet, error) {
+	snippet := listed(mockSnippet)
+	snippet.Views = 12
+
+	return []models.Snippet{snippet}, nil
+}
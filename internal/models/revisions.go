@@ -0,0 +1,91 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+type RevisionModelInterface interface {
+	List(snippetID int) ([]Revision, error)
+	Get(snippetID int, number int) (Revision, error)
+}
+
+// Define a Revision type which holds one version of the title and files of a
+// snippet. Revisions of a snippet are numbered from 1, and the UserID and
+// UserName fields identify the user who saved the revision.
+type Revision struct {
+	SnippetID int
+	Number    int
+	UserID    int
+	UserName  string
+	Title     string
+	Files     []File
+	Created   time.Time
+}
+
+// Define a RevisionModel type which wraps a sql.DB connection pool
+type RevisionModel struct {
+	DB *sql.DB
+}
+
+// The selectRevision query fetches every column of a revision, along with
+// the name of the user who saved it.
+const selectRevision = `SELECT r.snippet_id, r.revision, COALESCE(r.user_id, 0), COALESCE(u.name, ''),
+	r.title, r.created
+	FROM snippet_revisions r LEFT JOIN users u ON u.id = r.user_id`
+
+// List returns every revision of a snippet, most recent first. The files of
+// the revisions aren't included.
+func (m *RevisionModel) List(snippetID int) ([]Revision, error) {
+	stmt := selectRevision + ` WHERE r.snippet_id = ? ORDER BY r.revision DESC`
+
+	rows, err := m.DB.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+
+	for rows.Next() {
+		var r Revision
+
+		err = rows.Scan(&r.SnippetID, &r.Number, &r.UserID, &r.UserName, &r.Title, &r.Created)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Get returns a specific revision of a snippet along with its files, or
+// ErrNoRecord if the snippet has no revision with that number.
+func (m *RevisionModel) Get(snippetID int, number int) (Revision, error) {
+	stmt := selectRevision + ` WHERE r.snippet_id = ? AND r.revision = ?`
+
+	var r Revision
+
+	err := m.DB.QueryRow(stmt, snippetID, number).Scan(&r.SnippetID, &r.Number, &r.UserID, &r.UserName,
+		&r.Title, &r.Created)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Revision{}, ErrNoRecord
+		} else {
+			return Revision{}, err
+		}
+	}
+
+	r.Files, err = selectFiles(m.DB, snippetID, number)
+	if err != nil {
+		return Revision{}, err
+	}
+
+	return r, nil
+}
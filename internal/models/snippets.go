@@ -1,25 +1,81 @@
 package models
 
 import (
+	"crypto/rand"
 	"database/sql"
+	"encoding/base64"
 	"errors"
+	"slices"
+	"strings"
 	"time"
 )
 
+// The visibility of a snippet controls who can see it and where it is listed.
+const (
+	// Public snippets are shown in listings and search results.
+	VisibilityPublic = "public"
+	// Unlisted snippets can be viewed by anyone who knows their slug.
+	VisibilityUnlisted = "unlisted"
+	// Private snippets can only be viewed by their owner.
+	VisibilityPrivate = "private"
+)
+
 type SnippetModelInterface interface {
-	Insert(title string, content string, expires int) (int, error)
+	Insert(userID int, title string, files []File, visibility string, expires time.Time, burn bool) (int, error)
 	Get(id int) (Snippet, error)
-	Latest() ([]Snippet, error)
+	GetBySlug(slug string) (Snippet, error)
+	Update(id int, userID int, title string, files []File, visibility string, expires time.Time, burn bool) error
+	Delete(id int) error
+	DeleteExpired(limit int) (int, error)
+	Fork(id int, userID int) (int, error)
+	Burn(id int) (Snippet, error)
+	List(page int, pageSize int) ([]Snippet, int, error)
+	Search(query string, page int, pageSize int) ([]Snippet, int, error)
+	ListByTag(tag string, page int, pageSize int) ([]Snippet, int, error)
+	ListStarred(userID int, page int, pageSize int) ([]Snippet, int, error)
 }
 
 // Define a snippet type to hold the datat for an individual snippet
 // The fields of the struct correspond to the fields in the MySQL "snippets" table
+// The UserID and UserName fields identify the user who created the snippet.
+// The Slug is a random, unguessable identifier used in the URL of unlisted
+// snippets. BurnAfterReading snippets are deleted the first time someone
+// other than their owner views them. Revision is the number of the current
+// version of the snippet, see RevisionModel. ParentID is the ID of the
+// snippet this one was forked from, if any, and Forks the number of public
+// snippets forked from this one, Stars the number of users who starred it, and
+// Views the number of times it was viewed, see ViewModel. The Files of the
+// current revision are stored in the "snippet_files" table. Tags are stored
+// separately and filled in from the TagModel when needed. Snippets which
+// never expire have a zero Expires time.
 type Snippet struct {
-	ID      int
-	Title   string
-	Content string
-	Created time.Time
-	Expires time.Time
+	ID               int
+	UserID           int
+	UserName         string
+	ParentID         int
+	Forks            int
+	Stars            int
+	Views            int
+	Title            string
+	Files            []File
+	Visibility       string
+	Slug             string
+	BurnAfterReading bool
+	Revision         int
+	Created          time.Time
+	Expires          time.Time
+	Tags             []string
+}
+
+// Content returns the content of all the files of the snippet, separated by
+// blank lines.
+func (s Snippet) Content() string {
+	contents := make([]string, len(s.Files))
+	for i, f := range s.Files {
+		contents[i] = f.Content
+	}
+
+	return strings.Join(contents, "\n\n")
 }
 
 // Define a SnippetModel type which wraps a sql.DB connection pool
@@ -27,14 +83,31 @@ type SnippetModel struct {
 	DB *sql.DB
 }
 
-// This will insert a new snippet into the database
-func (m *SnippetModel) Insert(title string, content string, expires int) (int, error) {
-	stmt := `INSERT INTO snippets (title, content, created, expires)
-	VALUES(?, ?, UTC_TIMESTAMP(), DATE_ADD(UTC_TIMESTAMP(), INTERVAL ? DAY))`
+// This will insert a new snippet owned by the given user into the database,
+// along with its first revision. The snippet expires at the given time, or
+// never if it is zero. A zero userID creates an anonymous snippet, which
+// nobody owns. Every snippet gets a slug, so that its visibility can be
+// changed to unlisted later on.
+func (m *SnippetModel) Insert(userID int, title string, files []File, visibility string, expires time.Time, burn bool) (int, error) {
+	slug, err := NewSlug()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	// Rollback() is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO snippets (user_id, title, visibility, slug, burn_after_reading, revision, created, expires)
+	VALUES(?, ?, ?, ?, ?, 1, UTC_TIMESTAMP(), ?)`
 
 	// DB.Exec is used to execute statements which don't return rows (like INSERT
 	// and DELETE)
-	result, err := m.DB.Exec(stmt, title, content, expires)
+	result, err := tx.Exec(stmt, nullID(userID), title, visibility, slug, burn, nullTime(expires))
 	if err != nil {
 		return 0, err
 	}
@@ -46,29 +119,105 @@ func (m *SnippetModel) Insert(title string, content string, expires int) (int, e
 		return 0, err
 	}
 
+	err = insertRevision(tx, int(id), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertFiles(tx, int(id), 1, files)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
 	return int(id), nil
 }
 
-// This will return a specific snippet based on it's ID
+// The insertRevision() helper copies the current title of a snippet into the
+// snippet_revisions table, recording the given user as its author. The files
+// of the revision are inserted separately with insertFiles().
+func insertRevision(tx *sql.Tx, snippetID int, userID int) error {
+	stmt := `INSERT INTO snippet_revisions (snippet_id, revision, user_id, title, created)
+	SELECT id, revision, ?, title, UTC_TIMESTAMP() FROM snippets WHERE id = ?`
+
+	_, err := tx.Exec(stmt, nullID(userID), snippetID)
+	return err
+}
+
+// The starCount expression counts the stars of the snippet s.
+const starCount = `(SELECT COUNT(*) FROM stars sr WHERE sr.snippet_id = s.id)`
+
+// The forkCount expression counts the forks of the snippet s which anyone
+// can open, so that the count doesn't give away private or unlisted forks.
+const forkCount = `(SELECT COUNT(*) FROM snippets f WHERE f.parent_id = s.id
+	AND f.visibility = 'public' AND (f.expires IS NULL OR f.expires > UTC_TIMESTAMP()))`
+
+// The selectSnippet query fetches every column of a single snippet, along
+// with the name of the user who created it. The columns are in the order
+// expected by scanSnippet().
+const selectSnippet = `SELECT s.id, COALESCE(s.user_id, 0), COALESCE(u.name, ''),
+	COALESCE(s.parent_id, 0), ` + forkCount + `, ` + starCount + `,
+	(SELECT COALESCE(SUM(sv.views), 0) FROM snippet_views sv WHERE sv.snippet_id = s.id), s.title, s.visibility, COALESCE(s.slug, ''), s.burn_after_reading, s.revision, s.created, s.expires
+	FROM snippets s LEFT JOIN users u ON u.id = s.user_id`
+
+// This will return a specific snippet based on it's ID, along with the name
+// of the user who created it and its files
 func (m *SnippetModel) Get(id int) (Snippet, error) {
-	stmt := `SELECT id, title, content, created, expires FROM snippets
-	WHERE expires > UTC_TIMESTAMP() AND id = ?`
+	stmt := selectSnippet + ` WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.id = ?`
 
 	// Use the QueryRow() method on the connection pool to execute our
 	// SQL statement, passing in the untrusted id variable as the value
 	// for the placeholder parameter. This returns a pointer to a sql.Row
 	// object which holds the result from the database
-	row := m.DB.QueryRow(stmt, id)
+	s, err := scanSnippet(m.DB.QueryRow(stmt, id))
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	s.Files, err = selectFiles(m.DB, s.ID, s.Revision)
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	return s, nil
+}
+
+// This will return a specific snippet based on its random slug
+func (m *SnippetModel) GetBySlug(slug string) (Snippet, error) {
+	stmt := selectSnippet + ` WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.slug = ?`
+
+	s, err := scanSnippet(m.DB.QueryRow(stmt, slug))
+	if err != nil {
+		return Snippet{}, err
+	}
 
-	// Initialize a new zeroed Snippet struct
+	s.Files, err = selectFiles(m.DB, s.ID, s.Revision)
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	return s, nil
+}
+
+// The scanSnippet() helper copies a row returned by the selectSnippet query
+// into a Snippet struct.
+func scanSnippet(row *sql.Row) (Snippet, error) {
+	// Initialize a new zeroed Snippet struct, and a sql.NullTime for the
+	// expiry, which is NULL for snippets that never expire.
 	var s Snippet
+	var expires sql.NullTime
 
 	// Use row.Scan() to copy the values from each field in sql.Row to the
 	// corresponding field in the Snippet struct. Notice that the arguments
 	// to row.Scan are *pointers* to the place you want to copy the data into,
 	// and the number of arguments must be exactly the same as the number of the
 	// columns returned by your statement
-	err := row.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires)
+	err := row.Scan(&s.ID, &s.UserID, &s.UserName, &s.ParentID, &s.Forks, &s.Stars, &s.Views, &s.Title,
+		&s.Visibility, &s.Slug, &s.BurnAfterReading, &s.Revision, &s.Created, &expires)
 	if err != nil {
 		// If the query returns no rows, then row.Scan() will return a
 		// sql.ErrNoRows error. We use the errors.Is() function to check for
@@ -80,52 +229,450 @@ func (m *SnippetModel) Get(id int) (Snippet, error) {
 		}
 	}
 
+	s.Expires = expires.Time
+
+	return s, nil
+}
+
+// The nullTime() helper converts an expiry time into a value which can be
+// stored in the expires column, where NULL means never.
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// The nullID() helper converts a user ID into a value which can be stored in
+// a user_id column, where NULL means nobody.
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
+
+// This will update the title, files, visibility, expiry and
+// burn-after-reading flag of an existing snippet on behalf of the given user.
+// A zero expiry means that the snippet never expires. If the title or files
+// changed a new revision is recorded. Snippets created before slugs were
+// introduced are given one.
+func (m *SnippetModel) Update(id int, userID int, title string, files []File, visibility string, expires time.Time, burn bool) error {
+	slug, err := NewSlug()
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	// Lock the snippet while comparing it with the new version, so that two
+	// concurrent edits can't be given the same revision number.
+	var current Revision
+	var currentVisibility string
+
+	err = tx.QueryRow("SELECT title, visibility, revision FROM snippets WHERE id = ? FOR UPDATE", id).
+		Scan(&current.Title, &currentVisibility, &current.Number)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrNoRecord
+		} else {
+			return err
+		}
+	}
+
+	current.Files, err = selectFiles(tx, id, current.Number)
+	if err != nil {
+		return err
+	}
+
+	changed := current.Title != title || !slices.Equal(current.Files, files)
+
+	// A snippet which is made unlisted gets a new slug, as its old one might
+	// have been seen while the snippet was public.
+	newSlug := visibility == VisibilityUnlisted && currentVisibility != VisibilityUnlisted
+
+	stmt := `UPDATE snippets SET title = ?, visibility = ?,
+	slug = IF(?, ?, COALESCE(slug, ?)), burn_after_reading = ?, expires = ?,
+	revision = revision + ?
+	WHERE id = ?`
+
+	// MySQL treats booleans as the integers 0 and 1.
+	_, err = tx.Exec(stmt, title, visibility, newSlug, slug, slug, burn, nullTime(expires), changed, id)
+	if err != nil {
+		return err
+	}
+
+	if changed {
+		err = insertRevision(tx, id, userID)
+		if err != nil {
+			return err
+		}
+
+		err = insertFiles(tx, id, current.Number+1, files)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// This will remove a snippet from the database before it expires
+func (m *SnippetModel) Delete(id int) error {
+	stmt := `DELETE FROM snippets WHERE id = ?`
+
+	result, err := m.DB.Exec(stmt, id)
+	if err != nil {
+		return err
+	}
+
+	// If no rows were affected then there was no snippet with that ID.
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
+
+// DeleteExpired removes up to limit snippets which have expired, oldest
+// first, and returns how many were deleted. Keeping each call small stops a
+// large backlog of expired snippets from locking the table for long; the
+// files, revisions, comments and stars of the snippets go with them.
+func (m *SnippetModel) DeleteExpired(limit int) (int, error) {
+	stmt := `DELETE FROM snippets WHERE expires IS NOT NULL AND expires <= UTC_TIMESTAMP()
+	ORDER BY expires LIMIT ?`
+
+	result, err := m.DB.Exec(stmt, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rows), nil
+}
+
+// This will copy an unexpired snippet into a new snippet owned by the given
+// user, and return the ID of the copy. The copy keeps the title, files and
+// visibility of the original, and expires in a year. Private
+// and burn-after-reading snippets can't be forked, so ErrNoRecord is
+// returned for them.
+func (m *SnippetModel) Fork(id int, userID int) (int, error) {
+	slug, err := NewSlug()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO snippets (user_id, parent_id, title, visibility, slug, burn_after_reading, revision, created, expires)
+	SELECT ?, id, title, visibility, ?, FALSE, 1, UTC_TIMESTAMP(), DATE_ADD(UTC_TIMESTAMP(), INTERVAL 365 DAY)
+	FROM snippets
+	WHERE id = ? AND (expires IS NULL OR expires > UTC_TIMESTAMP()) AND visibility <> 'private' AND NOT burn_after_reading`
+
+	result, err := tx.Exec(stmt, userID, slug, id)
+	if err != nil {
+		return 0, err
+	}
+
+	// If no rows were inserted then there was no snippet which could be forked.
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if rows == 0 {
+		return 0, ErrNoRecord
+	}
+
+	forkID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	err = insertRevision(tx, int(forkID), userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Copy the files of the current revision of the original snippet.
+	stmt = `INSERT INTO snippet_files (snippet_id, revision, position, name, language, content)
+	SELECT ?, 1, f.position, f.name, f.language, f.content
+	FROM snippet_files f INNER JOIN snippets s ON s.id = f.snippet_id AND s.revision = f.revision
+	WHERE s.id = ?`
+
+	_, err = tx.Exec(stmt, forkID, id)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(forkID), nil
+}
+
+// This will atomically fetch and delete a burn-after-reading snippet. The row
+// is locked while it is read, so if two viewers race only one of them gets
+// the snippet and the other gets ErrNoRecord.
+func (m *SnippetModel) Burn(id int) (Snippet, error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	// Rollback() is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	stmt := selectSnippet + ` WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.id = ?
+	AND s.burn_after_reading FOR UPDATE OF s`
+
+	s, err := scanSnippet(tx.QueryRow(stmt, id))
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	// The files must be read before the snippet is deleted, as they are
+	// deleted along with it.
+	s.Files, err = selectFiles(tx, s.ID, s.Revision)
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	_, err = tx.Exec("DELETE FROM snippets WHERE id = ?", id)
+	if err != nil {
+		return Snippet{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Snippet{}, err
+	}
+
 	return s, nil
 }
 
-// This will return the 10 most recently created snippets
-func (m *SnippetModel) Latest() ([]Snippet, error) {
-	stmt := `SELECT id, title, content, created, expires FROM snippets
-	WHERE expires > UTC_TIMESTAMP() ORDER BY id DESC LIMIT 10`
+// This will return one page of the unexpired public snippets, most recent
+// first, along with the total number of such snippets. Burn-after-reading
+// snippets are never listed. Pages are numbered from 1.
+func (m *SnippetModel) List(page int, pageSize int) ([]Snippet, int, error) {
+	// Count the records first, so that callers can work out how many pages
+	// there are even when the requested page is out of range.
+	var total int
+
+	err := m.DB.QueryRow(`SELECT COUNT(*) FROM snippets
+	WHERE (expires IS NULL OR expires > UTC_TIMESTAMP()) AND visibility = 'public' AND NOT burn_after_reading`).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stmt := `SELECT ` + listColumns + ` FROM snippets s
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading
+	ORDER BY s.id DESC LIMIT ? OFFSET ?`
 
 	// Use the Query() method on the connection pool to execute our
 	// SQL statement. This returns a SQL.Rows resultset containing
 	// the result of our query.
-	rows, err := m.DB.Query(stmt)
+	rows, err := m.DB.Query(stmt, pageSize, (page-1)*pageSize)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	// We defer rows.Close() to ensure the sqL.Rows() resultset is
-	// always properly closed before the Latest() method returns. This defer
-	// statement should come *after* you check for an error from the Query()
-	// method. Otherwise, if Query() returns an error, you'll get a panic trying
-	// to close a nil resultset
+	// The scanSnippets() helper reads the rows into Snippet structs and
+	// closes the resultset, like for the other listing queries.
+	snippets, err := scanSnippets(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return snippets, total, nil
+}
+
+// The searchMatch condition matches snippets whose title, or the name or
+// content of one of their current files, match the search query. It relies
+// on the snippets_ft_title and snippet_files_ft_name_content FULLTEXT
+// indexes, and takes the query twice.
+const searchMatch = `(MATCH(s.title) AGAINST(? IN NATURAL LANGUAGE MODE) OR EXISTS (
+	SELECT 1 FROM snippet_files f WHERE f.snippet_id = s.id AND f.revision = s.revision
+	AND MATCH(f.name, f.content) AGAINST(? IN NATURAL LANGUAGE MODE)))`
+
+// The searchScore expression ranks the snippets matched by searchMatch, by
+// adding the relevance of the title to that of the best matching file. It
+// takes the query twice.
+const searchScore = `MATCH(s.title) AGAINST(? IN NATURAL LANGUAGE MODE) + COALESCE((
+	SELECT MAX(MATCH(f.name, f.content) AGAINST(? IN NATURAL LANGUAGE MODE))
+	FROM snippet_files f WHERE f.snippet_id = s.id AND f.revision = s.revision), 0)`
+
+// This will return one page of the unexpired public snippets whose title or
+// files match the query, most relevant first, along with the total number
+// of matches. The files of the snippets are included.
+func (m *SnippetModel) Search(query string, page int, pageSize int) ([]Snippet, int, error) {
+	var total int
+
+	stmt := `SELECT COUNT(*) FROM snippets s
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading
+	AND ` + searchMatch
+
+	err := m.DB.QueryRow(stmt, query, query).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stmt = `SELECT ` + listColumns + ` FROM snippets s
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading
+	AND ` + searchMatch + `
+	ORDER BY ` + searchScore + ` DESC, s.id DESC
+	LIMIT ? OFFSET ?`
+
+	rows, err := m.DB.Query(stmt, query, query, query, query, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	snippets, err := scanSnippets(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The search results show an excerpt of the matching content.
+	err = attachFiles(m.DB, snippets)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return snippets, total, nil
+}
+
+// This will return one page of the unexpired public snippets with the given
+// tag, most recent first, along with the total number of such snippets.
+func (m *SnippetModel) ListByTag(tag string, page int, pageSize int) ([]Snippet, int, error) {
+	var total int
+
+	stmt := `SELECT COUNT(*) FROM snippets s
+	INNER JOIN snippet_tags st ON st.snippet_id = s.id
+	INNER JOIN tags t ON t.id = st.tag_id
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading
+	AND t.name = ?`
+
+	err := m.DB.QueryRow(stmt, tag).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stmt = `SELECT ` + listColumns + ` FROM snippets s
+	INNER JOIN snippet_tags st ON st.snippet_id = s.id
+	INNER JOIN tags t ON t.id = st.tag_id
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading
+	AND t.name = ?
+	ORDER BY s.id DESC LIMIT ? OFFSET ?`
+
+	rows, err := m.DB.Query(stmt, tag, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	snippets, err := scanSnippets(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return snippets, total, nil
+}
+
+// This will return one page of the unexpired snippets starred by a user,
+// most recently starred first, along with the total number of such
+// snippets. Private snippets are only included if the user owns them, and
+// burn-after-reading snippets are never listed.
+func (m *SnippetModel) ListStarred(userID int, page int, pageSize int) ([]Snippet, int, error) {
+	var total int
+
+	stmt := `SELECT COUNT(*) FROM snippets s
+	INNER JOIN stars st ON st.snippet_id = s.id
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND (s.visibility <> 'private' OR s.user_id = st.user_id)
+	AND NOT s.burn_after_reading AND st.user_id = ?`
+
+	err := m.DB.QueryRow(stmt, userID).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	stmt = `SELECT ` + listColumns + ` FROM snippets s
+	INNER JOIN stars st ON st.snippet_id = s.id
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND (s.visibility <> 'private' OR s.user_id = st.user_id)
+	AND NOT s.burn_after_reading AND st.user_id = ?
+	ORDER BY st.created DESC, s.id DESC LIMIT ? OFFSET ?`
+
+	rows, err := m.DB.Query(stmt, userID, pageSize, (page-1)*pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	snippets, err := scanSnippets(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return snippets, total, nil
+}
+
+// The authorName expression looks up the name of the user who created the
+// snippet s, which is empty for anonymous snippets.
+const authorName = `COALESCE((SELECT u.name FROM users u WHERE u.id = s.user_id), '')`
+
+// The listColumns are selected by the listing queries, from the snippets
+// table aliased as s, in the order expected by scanSnippets().
+const listColumns = `s.id, s.title, ` + authorName + `, s.visibility, COALESCE(s.slug, ''), ` + starCount + `, s.created, s.expires`
+
+// The scanSnippets() helper reads every row of a listing query which selects
+// the listColumns, then closes the rows.
+func scanSnippets(rows *sql.Rows) ([]Snippet, error) {
 	defer rows.Close()
 
-	// Initialize an empty slice to hold the Snippet structs.
 	var snippets []Snippet
 
-	// Use rows.Next to iterate through the rows in the resultset. This
-	// prepares the first (and then each subssequent) row to be acted on by the
-	// rows.Scan() method. If iteration over all the rows completes then the
-	// resultset automatically closes itself and frees-up the underlying
-	// databse connection.
 	for rows.Next() {
 		var s Snippet
+		var expires sql.NullTime
 
-		err = rows.Scan(&s.ID, &s.Title, &s.Content, &s.Created, &s.Expires)
+		err := rows.Scan(&s.ID, &s.Title, &s.UserName, &s.Visibility, &s.Slug, &s.Stars, &s.Created, &expires)
 		if err != nil {
 			return nil, err
 		}
+		s.Expires = expires.Time
 		snippets = append(snippets, s)
 	}
 
-	// When the rows.Next() loop has finished we call rows.Err() to retrieve any
-	// error that was encountered during the iteration.
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 
 	return snippets, nil
 }
+
+// NewSlug generates a random, URL-safe identifier of 22 characters, which
+// is used as the slug of unlisted snippets and collections so that their
+// URLs can't be guessed.
+func NewSlug() (string, error) {
+	b := make([]byte, 16)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type StarModelInterface interface {
+	Insert(userID int, snippetID int) error
+	Delete(userID int, snippetID int) error
+	Exists(userID int, snippetID int) (bool, error)
+}
+
+// Define a StarModel type which wraps a sql.DB connection pool. The snippets
+// starred by a user are listed by SnippetModel.ListStarred().
+type StarModel struct {
+	DB *sql.DB
+}
+
+// Insert stars a snippet on behalf of a user. Starring a snippet twice has
+// no effect.
+func (m *StarModel) Insert(userID int, snippetID int) error {
+	stmt := `INSERT IGNORE INTO stars (user_id, snippet_id, created)
+	VALUES(?, ?, UTC_TIMESTAMP())`
+
+	_, err := m.DB.Exec(stmt, userID, snippetID)
+	return err
+}
+
+// Delete removes the star of a user from a snippet, if there is one.
+func (m *StarModel) Delete(userID int, snippetID int) error {
+	_, err := m.DB.Exec("DELETE FROM stars WHERE user_id = ? AND snippet_id = ?", userID, snippetID)
+	return err
+}
+
+// Exists reports whether a user has starred a snippet.
+func (m *StarModel) Exists(userID int, snippetID int) (bool, error) {
+	var exists bool
+
+	stmt := "SELECT EXISTS(SELECT true FROM stars WHERE user_id = ? AND snippet_id = ?)"
+
+	err := m.DB.QueryRow(stmt, userID, snippetID).Scan(&exists)
+	return exists, err
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"database/sql"
+)
+
+type TagModelInterface interface {
+	Set(snippetID int, names []string) error
+	ForSnippet(snippetID int) ([]string, error)
+	Cloud(limit int) ([]Tag, error)
+}
+
+// Define a Tag type which holds a tag name and the number of unexpired
+// snippets it is attached to.
+type Tag struct {
+	Name  string
+	Count int
+}
+
+// Define a TagModel type which wraps a sql.DB connection pool
+type TagModel struct {
+	DB *sql.DB
+}
+
+// Set replaces the tags of a snippet with the given names, creating any
+// tags which don't exist yet. All the changes happen in one transaction.
+func (m *TagModel) Set(snippetID int, names []string) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback() is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM snippet_tags WHERE snippet_id = ?", snippetID)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		_, err = tx.Exec("INSERT IGNORE INTO tags (name) VALUES(?)", name)
+		if err != nil {
+			return err
+		}
+
+		stmt := `INSERT INTO snippet_tags (snippet_id, tag_id)
+		SELECT ?, id FROM tags WHERE name = ?`
+
+		_, err = tx.Exec(stmt, snippetID, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ForSnippet returns the names of the tags attached to a snippet in
+// alphabetical order.
+func (m *TagModel) ForSnippet(snippetID int) ([]string, error) {
+	stmt := `SELECT t.name FROM tags t
+	INNER JOIN snippet_tags st ON st.tag_id = t.id
+	WHERE st.snippet_id = ? ORDER BY t.name`
+
+	rows, err := m.DB.Query(stmt, snippetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var names []string
+
+	for rows.Next() {
+		var name string
+
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// Cloud returns up to limit tags which are attached to unexpired public
+// snippets, most used first.
+func (m *TagModel) Cloud(limit int) ([]Tag, error) {
+	stmt := `SELECT t.name, COUNT(*) FROM tags t
+	INNER JOIN snippet_tags st ON st.tag_id = t.id
+	INNER JOIN snippets s ON s.id = st.snippet_id
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public' AND NOT s.burn_after_reading
+	GROUP BY t.name ORDER BY COUNT(*) DESC, t.name LIMIT ?`
+
+	rows, err := m.DB.Query(stmt, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tags []Tag
+
+	for rows.Next() {
+		var t Tag
+
+		err = rows.Scan(&t.Name, &t.Count)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+type TokenModelInterface interface {
+	Insert(userID int, name string, expires time.Time) (string, error)
+	Authenticate(token string) (int, error)
+	ListByUser(userID int) ([]Token, error)
+	Delete(id int, userID int) error
+}
+
+// Define a Token type which holds the details of a personal API token. The
+// token itself is never stored, only its hash. Tokens which never expire,
+// or haven't been used yet, have a zero Expires or LastUsed time.
+type Token struct {
+	ID       int
+	UserID   int
+	Name     string
+	Created  time.Time
+	Expires  time.Time
+	LastUsed time.Time
+}
+
+// TokenPrefix starts every API token, so that they are easy to recognise,
+// for example by secret scanners.
+const TokenPrefix = "sbx_"
+
+// Define a TokenModel type which wraps a sql.DB connection pool
+type TokenModel struct {
+	DB *sql.DB
+}
+
+// The hashToken() helper returns the hex-encoded SHA-256 hash of a token. A
+// fast hash is enough, unlike for passwords, as tokens are long and random.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Insert creates a new API token for a user, which expires at the given time
+// or never if it is zero, and returns the token. This is the only time the
+// token is available, as only its hash is stored.
+func (m *TokenModel) Insert(userID int, name string, expires time.Time) (string, error) {
+	b := make([]byte, 32)
+
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	token := TokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	stmt := `INSERT INTO api_tokens (user_id, name, hash, created, expires)
+	VALUES(?, ?, ?, UTC_TIMESTAMP(), ?)`
+
+	_, err = m.DB.Exec(stmt, userID, name, hashToken(token), nullTime(expires))
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Authenticate returns the ID of the user who owns an unexpired token, and
+// records that the token was used. If there is no such token
+// ErrInvalidCredentials is returned.
+func (m *TokenModel) Authenticate(token string) (int, error) {
+	if !strings.HasPrefix(token, TokenPrefix) {
+		return 0, ErrInvalidCredentials
+	}
+
+	var id, userID int
+
+	stmt := `SELECT id, user_id FROM api_tokens
+	WHERE hash = ? AND (expires IS NULL OR expires > UTC_TIMESTAMP())`
+
+	err := m.DB.QueryRow(stmt, hashToken(token)).Scan(&id, &userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, ErrInvalidCredentials
+		} else {
+			return 0, err
+		}
+	}
+
+	// Only record the time of use once a minute, so that busy scripts don't
+	// write to the database on every request.
+	stmt = `UPDATE api_tokens SET last_used = UTC_TIMESTAMP()
+	WHERE id = ? AND (last_used IS NULL OR last_used < UTC_TIMESTAMP() - INTERVAL 1 MINUTE)`
+
+	_, err = m.DB.Exec(stmt, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+// ListByUser returns every API token of a user, including expired ones, most
+// recently created first.
+func (m *TokenModel) ListByUser(userID int) ([]Token, error) {
+	stmt := `SELECT id, user_id, name, created, expires, last_used FROM api_tokens
+	WHERE user_id = ? ORDER BY created DESC, id DESC`
+
+	rows, err := m.DB.Query(stmt, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tokens []Token
+
+	for rows.Next() {
+		var t Token
+		var expires, lastUsed sql.NullTime
+
+		err := rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Created, &expires, &lastUsed)
+		if err != nil {
+			return nil, err
+		}
+		t.Expires = expires.Time
+		t.LastUsed = lastUsed.Time
+		tokens = append(tokens, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Delete revokes an API token of a user. If the user has no token with the
+// given ID ErrNoRecord is returned.
+func (m *TokenModel) Delete(id int, userID int) error {
+	result, err := m.DB.Exec("DELETE FROM api_tokens WHERE id = ? AND user_id = ?", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNoRecord
+	}
+
+	return nil
+}
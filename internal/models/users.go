@@ -14,6 +14,7 @@ type UserModelInterface interface {
 	Insert(name, email, password string) error
 	Authenticate(email, password string) (int, error)
 	Exists(id int) (bool, error)
+	Get(id int) (User, error)
 }
 
 // Define a new User struct.
@@ -23,6 +24,7 @@ type User struct {
 	Email          string
 	HashedPassword []byte
 	Created        time.Time
+	IsAdmin        bool
 }
 
 // Define a new UserModel struct which wraps a database conncetion pool
@@ -99,3 +101,22 @@ func (m *UserModel) Exists(id int) (bool, error) {
 	err := m.DB.QueryRow(stmt, id).Scan(&exists)
 	return exists, err
 }
+
+// Get method to retrieve the details of a specific user, excluding their
+// password hash.
+func (m *UserModel) Get(id int) (User, error) {
+	var user User
+
+	stmt := "SELECT id, name, email, created, is_admin FROM users WHERE id = ?"
+
+	err := m.DB.QueryRow(stmt, id).Scan(&user.ID, &user.Name, &user.Email, &user.Created, &user.IsAdmin)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return User{}, ErrNoRecord
+		} else {
+			return User{}, err
+		}
+	}
+
+	return user, nil
+}
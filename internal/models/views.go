@@ -0,0 +1,82 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+type ViewModelInterface interface {
+	Add(counts map[int]int) error
+	MostViewed(since time.Time, limit int) ([]Snippet, error)
+}
+
+// Define a ViewModel type which wraps a sql.DB connection pool. Views are
+// counted per snippet and per day; the total for a snippet is filled in by
+// SnippetModel.Get().
+type ViewModel struct {
+	DB *sql.DB
+}
+
+// Add records views of snippets on the current day, given as a map of
+// snippet IDs to the number of views. Views of snippets which have been
+// deleted in the meantime are ignored.
+func (m *ViewModel) Add(counts map[int]int) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+
+	// Rollback() is a no-op once the transaction has been committed.
+	defer tx.Rollback()
+
+	stmt := `INSERT INTO snippet_views (snippet_id, day, views)
+	SELECT id, UTC_DATE(), ? FROM snippets WHERE id = ?
+	ON DUPLICATE KEY UPDATE views = views + ?`
+
+	for snippetID, n := range counts {
+		_, err = tx.Exec(stmt, n, snippetID, n)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// MostViewed returns up to limit unexpired public snippets which were viewed
+// on or after the day of the given time, most viewed first. The Views of
+// the snippets only count the views over that period.
+func (m *ViewModel) MostViewed(since time.Time, limit int) ([]Snippet, error) {
+	stmt := `SELECT ` + listColumns + `, v.views FROM snippets s
+	INNER JOIN (SELECT snippet_id, SUM(views) AS views FROM snippet_views
+		WHERE day >= ? GROUP BY snippet_id) v ON v.snippet_id = s.id
+	WHERE (s.expires IS NULL OR s.expires > UTC_TIMESTAMP()) AND s.visibility = 'public'
+	AND NOT s.burn_after_reading
+	ORDER BY v.views DESC, s.id DESC LIMIT ?`
+
+	rows, err := m.DB.Query(stmt, since.UTC().Format(time.DateOnly), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snippets []Snippet
+
+	for rows.Next() {
+		var s Snippet
+		var expires sql.NullTime
+
+		err := rows.Scan(&s.ID, &s.Title, &s.UserName, &s.Visibility, &s.Slug, &s.Stars, &s.Created, &expires, &s.Views)
+		if err != nil {
+			return nil, err
+		}
+		s.Expires = expires.Time
+		snippets = append(snippets, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return snippets, nil
+}
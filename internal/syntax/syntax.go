@@ -0,0 +1,177 @@
+// Package syntax renders snippet content as syntax highlighted HTML. The
+// output uses CSS classes rather than inline styles, so that it is allowed by
+// the Content-Security-Policy header; the matching stylesheet lives in
+// ui/static/css/syntax.css.
+package syntax
+
+import (
+	"bytes"
+	"html/template"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// PlainText is the name of the language used for content which shouldn't be
+// highlighted.
+const PlainText = "text"
+
+// Define a Language type which holds the name of a language, as stored in the
+// database, a label to display to users and the file extension used when
+// downloading snippets.
+type Language struct {
+	Name      string
+	Label     string
+	Extension string
+}
+
+// Languages lists the languages which can be chosen for a snippet.
+var Languages = []Language{
+	{Name: PlainText, Label: "Plain text", Extension: ".txt"},
+	{Name: "bash", Label: "Bash", Extension: ".sh"},
+	{Name: "c", Label: "C", Extension: ".c"},
+	{Name: "cpp", Label: "C++", Extension: ".cpp"},
+	{Name: "css", Label: "CSS", Extension: ".css"},
+	{Name: "diff", Label: "Diff", Extension: ".diff"},
+	{Name: "docker", Label: "Dockerfile", Extension: ".dockerfile"},
+	{Name: "go", Label: "Go", Extension: ".go"},
+	{Name: "html", Label: "HTML", Extension: ".html"},
+	{Name: "ini", Label: "INI", Extension: ".ini"},
+	{Name: "java", Label: "Java", Extension: ".java"},
+	{Name: "javascript", Label: "JavaScript", Extension: ".js"},
+	{Name: "json", Label: "JSON", Extension: ".json"},
+	{Name: "makefile", Label: "Makefile", Extension: ".mk"},
+	{Name: "markdown", Label: "Markdown", Extension: ".md"},
+	{Name: "php", Label: "PHP", Extension: ".php"},
+	{Name: "python", Label: "Python", Extension: ".py"},
+	{Name: "ruby", Label: "Ruby", Extension: ".rb"},
+	{Name: "rust", Label: "Rust", Extension: ".rs"},
+	{Name: "sql", Label: "SQL", Extension: ".sql"},
+	{Name: "toml", Label: "TOML", Extension: ".toml"},
+	{Name: "typescript", Label: "TypeScript", Extension: ".ts"},
+	{Name: "xml", Label: "XML", Extension: ".xml"},
+	{Name: "yaml", Label: "YAML", Extension: ".yaml"},
+}
+
+// The formatter writes class-based HTML without a surrounding <pre> element,
+// so that templates control the markup around the code.
+var formatter = html.New(html.WithClasses(true), html.PreventSurroundingPre(true))
+
+// The style only matters when generating the stylesheet, as the formatter
+// emits class names rather than colours.
+var style = styles.Get("github")
+
+// Names returns the names of all the supported languages.
+func Names() []string {
+	names := make([]string, len(Languages))
+	for i, l := range Languages {
+		names[i] = l.Name
+	}
+
+	return names
+}
+
+// lookup returns the details of a language, falling back to plain text for
+// unknown languages.
+func lookup(name string) Language {
+	for _, l := range Languages {
+		if l.Name == name {
+			return l
+		}
+	}
+
+	return Languages[0]
+}
+
+// Label returns the display label of a language.
+func Label(name string) string {
+	return lookup(name).Label
+}
+
+// Extension returns the file extension of a language, including the leading
+// dot.
+func Extension(name string) string {
+	return lookup(name).Extension
+}
+
+// ForFile returns the name of the language of a file, guessed from its
+// extension. Files with unknown extensions are plain text.
+func ForFile(filename string) string {
+	ext := strings.ToLower(filepath.Ext(filename))
+
+	for _, l := range Languages {
+		if l.Extension == ext {
+			return l.Name
+		}
+	}
+
+	return PlainText
+}
+
+// lexer returns the chroma lexer for a language. Unknown languages use the
+// plain text lexer, which leaves the content as it is.
+func lexer(name string) chroma.Lexer {
+	l := lexers.Get(name)
+	if l == nil {
+		l = lexers.Get(PlainText)
+	}
+
+	// Coalesce runs of identical token types to keep the markup small.
+	return chroma.Coalesce(l)
+}
+
+// HTML returns the source code highlighted as the given language. The
+// content is HTML-escaped, so the result is safe to include in a template.
+func HTML(language, source string) (template.HTML, error) {
+	iterator, err := lexer(language).Tokenise(nil, source)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	err = formatter.Format(&buf, style, iterator)
+	if err != nil {
+		return "", err
+	}
+
+	return template.HTML(buf.String()), nil
+}
+
+// Lines returns the source code highlighted as the given language, split
+// into lines without their line breaks. Each line is HTML-escaped and only
+// contains complete elements, so that it can be shown on its own.
+func Lines(language, source string) ([]template.HTML, error) {
+	iterator, err := lexer(language).Tokenise(nil, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []template.HTML
+
+	for _, tokens := range chroma.SplitTokensIntoLines(iterator.Tokens()) {
+		// The last token of every line but the final one ends with the line
+		// break, which is dropped along with the token if nothing is left.
+		if n := len(tokens); n > 0 {
+			tokens[n-1].Value = strings.TrimSuffix(tokens[n-1].Value, "\n")
+			if tokens[n-1].Value == "" {
+				tokens = tokens[:n-1]
+			}
+		}
+
+		var buf bytes.Buffer
+
+		err = formatter.Format(&buf, style, chroma.Literator(tokens...))
+		if err != nil {
+			return nil, err
+		}
+
+		lines = append(lines, template.HTML(buf.String()))
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,109 @@
+package syntax
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Overlrd/snippetbox/internal/assert"
+)
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		source   string
+		want     string
+	}{
+		{
+			name:     "Go keyword",
+			language: "go",
+			source:   "func main() {}",
+			want:     `<span class="kd">func</span>`,
+		},
+		{
+			name:     "Plain text is escaped",
+			language: PlainText,
+			source:   "<script>alert(1)</script>",
+			want:     "&lt;script&gt;alert(1)&lt;/script&gt;",
+		},
+		{
+			name:     "Unknown language falls back to plain text",
+			language: "klingon",
+			source:   "<b>Qapla'</b>",
+			want:     "&lt;b&gt;Qapla&#39;&lt;/b&gt;",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := HTML(tt.language, tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assert.StringContains(t, string(got), tt.want)
+			assert.Equal(t, strings.Contains(string(got), "style="), false)
+		})
+	}
+}
+
+func TestLabel(t *testing.T) {
+	assert.Equal(t, Label("go"), "Go")
+	assert.Equal(t, Label("klingon"), "Plain text")
+}
+
+func TestExtension(t *testing.T) {
+	assert.Equal(t, Extension("python"), ".py")
+	assert.Equal(t, Extension("klingon"), ".txt")
+}
+
+func TestForFile(t *testing.T) {
+	assert.Equal(t, ForFile("main.go"), "go")
+	assert.Equal(t, ForFile("README.MD"), "markdown")
+	assert.Equal(t, ForFile("notes"), PlainText)
+	assert.Equal(t, ForFile("archive.tar.gz"), PlainText)
+}
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		source   string
+		want     []string
+	}{
+		{
+			name:     "Empty",
+			language: PlainText,
+			source:   "",
+			want:     nil,
+		},
+		{
+			name:     "Trailing line break",
+			language: PlainText,
+			source:   "a <b>\n\nc\n",
+			want:     []string{"a &lt;b&gt;", "", "c"},
+		},
+		{
+			name:     "Token spanning lines",
+			language: "go",
+			source:   "/* a\nb */\nx",
+			want:     []string{`<span class="cm">/* a</span>`, `<span class="cm">b */</span>`, `<span class="nx">x</span>`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := Lines(tt.language, tt.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := make([]string, len(lines))
+			for i, line := range lines {
+				got[i] = string(line)
+			}
+
+			assert.Equal(t, strings.Join(got, "|"), strings.Join(tt.want, "|"))
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package validator
 
 import (
+	"cmp"
 	"regexp"
 	"slices"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -12,6 +14,18 @@ import (
 // error.
 var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 
+// TagRX matches a tag name: up to 30 lowercase letters, digits and the
+// characters + # . _ -, starting with a letter or digit.
+var TagRX = regexp.MustCompile(`^[a-z0-9][a-z0-9+#._-]{0,29}$`)
+
+// FileNameRX matches a snippet file name: letters, digits and the characters
+// . _ -, so that it can be used as is in a URL path segment.
+var FileNameRX = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// SlugRX matches a collection slug: lowercase letters and digits, in words
+// separated by single hyphens.
+var SlugRX = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
 // Define a new validator struct which contains a mao of validation error messages
 // for our form fields
 type Validator struct {
@@ -74,3 +88,36 @@ func MinChars(value string, n int) bool {
 func Matches(value string, rx *regexp.Regexp) bool {
 	return rx.MatchString(value)
 }
+
+// Between() returns true if a value is no less than lo and no more than hi.
+func Between[T cmp.Ordered](value, lo, hi T) bool {
+	return value >= lo && value <= hi
+}
+
+// Future() returns true if a time is later than the current time.
+func Future(t time.Time) bool {
+	return t.After(time.Now())
+}
+
+// Within() returns true if a time is no later than the given duration from
+// the current time.
+func Within(t time.Time, d time.Duration) bool {
+	return !t.After(time.Now().Add(d))
+}
+
+// MaxItems() returns true if a slice contains no more than n items.
+func MaxItems[T any](values []T, n int) bool {
+	return len(values) <= n
+}
+
+// AllMatch() returns true if every value matches a provided compiled regular
+// expression
+func AllMatch(values []string, rx *regexp.Regexp) bool {
+	for _, value := range values {
+		if !rx.MatchString(value) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,5 @@
+-- Link every snippet to the user who created it. Snippets created before
+-- this migration have no owner, so the column is nullable.
+ALTER TABLE snippets ADD COLUMN user_id INTEGER NULL AFTER id;
+ALTER TABLE snippets ADD CONSTRAINT snippets_fk_user_id
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL;
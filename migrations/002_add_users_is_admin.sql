@@ -0,0 +1,3 @@
+-- Administrators may delete any snippet. Promote a user with:
+-- UPDATE users SET is_admin = TRUE WHERE email = '...';
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+-- Index snippet titles and content for full-text search.
+ALTER TABLE snippets ADD FULLTEXT INDEX snippets_ft_title_content (title, content);
@@ -0,0 +1,3 @@
+-- The language used to syntax highlight a snippet. Existing snippets are
+-- shown as plain text.
+ALTER TABLE snippets ADD COLUMN language VARCHAR(20) NOT NULL DEFAULT 'text' AFTER content;
@@ -0,0 +1,7 @@
+-- Public snippets are listed everywhere, unlisted snippets are only reachable
+-- through their random slug and private snippets are only visible to their
+-- owner. Existing snippets stay public and get a slug when they are next
+-- updated.
+ALTER TABLE snippets ADD COLUMN visibility ENUM('public', 'unlisted', 'private') NOT NULL DEFAULT 'public';
+ALTER TABLE snippets ADD COLUMN slug CHAR(22) NULL;
+ALTER TABLE snippets ADD CONSTRAINT snippets_uc_slug UNIQUE (slug);
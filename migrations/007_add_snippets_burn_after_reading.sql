@@ -0,0 +1,3 @@
+-- Burn-after-reading snippets are deleted the first time someone other than
+-- their owner views them.
+ALTER TABLE snippets ADD COLUMN burn_after_reading BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,21 @@
+-- Every version of the title, content and language of a snippet is kept in
+-- the snippet_revisions table. The snippets table holds the number of the
+-- current revision.
+ALTER TABLE snippets ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE snippet_revisions (
+    snippet_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    user_id INTEGER NULL,
+    title VARCHAR(100) NOT NULL,
+    content TEXT NOT NULL,
+    language VARCHAR(20) NOT NULL,
+    created DATETIME NOT NULL,
+    PRIMARY KEY (snippet_id, revision),
+    CONSTRAINT snippet_revisions_fk_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    CONSTRAINT snippet_revisions_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+-- Existing snippets start out with their current version as revision 1.
+INSERT INTO snippet_revisions (snippet_id, revision, user_id, title, content, language, created)
+SELECT id, 1, user_id, title, content, language, created FROM snippets;
@@ -0,0 +1,5 @@
+-- Forked snippets record the snippet they were copied from. Forks are kept
+-- when the original snippet is deleted.
+ALTER TABLE snippets ADD COLUMN parent_id INTEGER NULL AFTER user_id;
+ALTER TABLE snippets ADD CONSTRAINT snippets_fk_parent_id
+    FOREIGN KEY (parent_id) REFERENCES snippets(id) ON DELETE SET NULL;
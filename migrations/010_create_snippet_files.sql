@@ -0,0 +1,28 @@
+-- Snippets hold an ordered list of named files, each with its own language.
+-- The files of every revision are kept, and the current files of a snippet
+-- are those of its current revision.
+CREATE TABLE snippet_files (
+    snippet_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    language VARCHAR(20) NOT NULL,
+    content TEXT NOT NULL,
+    PRIMARY KEY (snippet_id, revision, position),
+    CONSTRAINT snippet_files_fk_revision FOREIGN KEY (snippet_id, revision)
+        REFERENCES snippet_revisions(snippet_id, revision) ON DELETE CASCADE
+);
+
+ALTER TABLE snippet_files ADD FULLTEXT INDEX snippet_files_ft_name_content (name, content);
+
+-- Move the content of every existing revision into a single file.
+INSERT INTO snippet_files (snippet_id, revision, position, name, language, content)
+SELECT snippet_id, revision, 0, 'snippet', language, content FROM snippet_revisions;
+
+ALTER TABLE snippet_revisions DROP COLUMN content, DROP COLUMN language;
+
+-- Titles are now searched on their own, and file contents through the
+-- snippet_files_ft_name_content index.
+ALTER TABLE snippets DROP INDEX snippets_ft_title_content;
+ALTER TABLE snippets DROP COLUMN content, DROP COLUMN language;
+ALTER TABLE snippets ADD FULLTEXT INDEX snippets_ft_title (title);
@@ -0,0 +1,11 @@
+-- Comments left by users on snippets. They are deleted along with the
+-- snippet or the user.
+CREATE TABLE comments (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    snippet_id INTEGER NOT NULL,
+    user_id INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    created DATETIME NOT NULL,
+    CONSTRAINT comments_fk_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE,
+    CONSTRAINT comments_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
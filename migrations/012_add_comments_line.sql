@@ -0,0 +1,7 @@
+-- Comments can be attached to a line of one of the files of a snippet. The
+-- revision is recorded too, since later edits may move or remove the line.
+-- General comments leave these columns NULL.
+ALTER TABLE comments
+    ADD COLUMN revision INTEGER NULL AFTER user_id,
+    ADD COLUMN file_name VARCHAR(100) NULL AFTER revision,
+    ADD COLUMN line INTEGER NULL AFTER file_name;
@@ -0,0 +1,13 @@
+-- Users can star snippets to find them again later. Stars are deleted along
+-- with the snippet or the user.
+CREATE TABLE stars (
+    user_id INTEGER NOT NULL,
+    snippet_id INTEGER NOT NULL,
+    created DATETIME NOT NULL,
+    PRIMARY KEY (user_id, snippet_id),
+    CONSTRAINT stars_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT stars_fk_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+-- Star counts are looked up by snippet.
+CREATE INDEX idx_stars_snippet_id ON stars(snippet_id);
@@ -0,0 +1,28 @@
+-- Collections are named, ordered lists of snippets curated by a user. They
+-- are reached through their slug, and have the same visibilities as
+-- snippets.
+CREATE TABLE collections (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    slug VARCHAR(50) NOT NULL,
+    title VARCHAR(100) NOT NULL,
+    description TEXT NOT NULL,
+    visibility VARCHAR(10) NOT NULL DEFAULT 'public',
+    created DATETIME NOT NULL,
+    CONSTRAINT collections_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+ALTER TABLE collections ADD CONSTRAINT collections_uc_slug UNIQUE (slug);
+
+-- The snippets of a collection, in order. Snippets leave their collections
+-- when they are deleted.
+CREATE TABLE collection_snippets (
+    collection_id INTEGER NOT NULL,
+    snippet_id INTEGER NOT NULL,
+    position INTEGER NOT NULL,
+    PRIMARY KEY (collection_id, snippet_id),
+    CONSTRAINT collection_snippets_fk_collection_id FOREIGN KEY (collection_id) REFERENCES collections(id) ON DELETE CASCADE,
+    CONSTRAINT collection_snippets_fk_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_collection_snippets_snippet_id ON collection_snippets(snippet_id);
@@ -0,0 +1,13 @@
+-- Views of snippets are counted per day, so that both the total number of
+-- views and the most viewed snippets of the last few days can be found.
+-- The counts are deleted along with the snippet.
+CREATE TABLE snippet_views (
+    snippet_id INTEGER NOT NULL,
+    day DATE NOT NULL,
+    views INTEGER NOT NULL,
+    PRIMARY KEY (snippet_id, day),
+    CONSTRAINT snippet_views_fk_snippet_id FOREIGN KEY (snippet_id) REFERENCES snippets(id) ON DELETE CASCADE
+);
+
+-- The most viewed snippets are looked up by day.
+CREATE INDEX idx_snippet_views_day ON snippet_views(day);
@@ -0,0 +1,15 @@
+-- Personal API tokens let scripts authenticate as a user. Only a SHA-256
+-- hash of each token is stored; the token itself is shown once, when it is
+-- created. Tokens never expire if expires is NULL, and are deleted along
+-- with the user.
+CREATE TABLE api_tokens (
+    id INTEGER NOT NULL PRIMARY KEY AUTO_INCREMENT,
+    user_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    hash CHAR(64) NOT NULL,
+    created DATETIME NOT NULL,
+    expires DATETIME NULL,
+    last_used DATETIME NULL,
+    CONSTRAINT api_tokens_uc_hash UNIQUE (hash),
+    CONSTRAINT api_tokens_fk_user_id FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -5,4 +5,156 @@ for (var i = 0; i < navLinks.length; i++) {
 		link.classList.add("live");
 		break;
 	}
-}
\ No newline at end of file
+}
+
+// Let the snippet form add and remove file blocks. New blocks are copies of
+// the last one with their values cleared, numbered after every block seen so
+// far so that the field names never clash. Gaps in the numbering are fine,
+// as empty blocks are dropped by the server.
+var addFileButton = document.getElementById("add-file");
+if (addFileButton) {
+	var fileCount = document.querySelectorAll("form .file").length;
+
+	addFileButton.addEventListener("click", function () {
+		var blocks = document.querySelectorAll("form .file");
+		var last = blocks[blocks.length - 1];
+		var block = last.cloneNode(true);
+
+		var fields = block.querySelectorAll("input, select, textarea");
+		for (var i = 0; i < fields.length; i++) {
+			var field = fields[i];
+			field.name = field.name.replace(/^files\[\d+\]/, "files[" + fileCount + "]");
+			if (field.tagName == "SELECT") {
+				field.selectedIndex = 0;
+			} else {
+				field.value = "";
+			}
+		}
+
+		var errors = block.querySelectorAll(".error");
+		for (var i = 0; i < errors.length; i++) {
+			errors[i].remove();
+		}
+
+		fileCount++;
+		last.after(block);
+	});
+
+	document.addEventListener("click", function (event) {
+		if (!event.target.classList.contains("remove-file")) {
+			return;
+		}
+
+		// Always keep at least one block.
+		if (document.querySelectorAll("form .file").length > 1) {
+			event.target.closest(".file").remove();
+		}
+	});
+}
+
+// Highlight the lines of a snippet named in the URL fragment, such as "#L10"
+// or "#L10-L20" for the first file and "#main.go-L10-L20" for the others.
+// Shift-clicking a line number extends the selection from the line which is
+// already highlighted.
+var codeTables = document.querySelectorAll("table.code");
+if (codeTables.length > 0) {
+	var lineRX = /^#(.*?)L(\d+)(?:-L(\d+))?$/;
+
+	var highlightLines = function () {
+		var rows = document.querySelectorAll("table.code tr.highlighted");
+		for (var i = 0; i < rows.length; i++) {
+			rows[i].classList.remove("highlighted");
+		}
+
+		var match = lineRX.exec(window.location.hash);
+		if (!match) {
+			return;
+		}
+
+		var prefix = decodeURIComponent(match[1]);
+		var start = parseInt(match[2], 10);
+		var end = match[3] ? parseInt(match[3], 10) : start;
+		if (end < start) {
+			var swap = start;
+			start = end;
+			end = swap;
+		}
+
+		var first = null;
+		for (var n = start; n <= end; n++) {
+			var row = document.getElementById(prefix + "L" + n);
+			if (!row) {
+				continue;
+			}
+			row.classList.add("highlighted");
+			first = first || row;
+
+			// The source of Markdown files is folded away by default.
+			var details = row.closest("details");
+			if (details) {
+				details.open = true;
+			}
+		}
+
+		if (first) {
+			first.scrollIntoView({block: "center"});
+		}
+	};
+
+	document.addEventListener("click", function (event) {
+		var link = event.target.closest("table.code td.number a");
+		if (!link || !event.shiftKey) {
+			return;
+		}
+
+		var current = lineRX.exec(window.location.hash);
+		var clicked = lineRX.exec(link.getAttribute("href"));
+		if (!current || !clicked || current[1] != clicked[1]) {
+			return;
+		}
+
+		event.preventDefault();
+
+		var lines = [parseInt(current[2], 10), parseInt(clicked[2], 10)].sort(function (a, b) {
+			return a - b;
+		});
+		window.location.hash = clicked[1] + "L" + lines[0] + "-L" + lines[1];
+	});
+
+	window.addEventListener("hashchange", highlightLines);
+	highlightLines();
+}
+
+// Let the comment buttons next to each line fill in the line of the comment
+// form, and move the focus to it.
+var commentForm = document.getElementById("comment-form");
+if (commentForm) {
+	document.addEventListener("click", function (event) {
+		var button = event.target.closest("td.add-comment button");
+		if (!button) {
+			return;
+		}
+
+		commentForm.elements["file"].value = button.dataset.file;
+		commentForm.elements["line"].value = button.dataset.line;
+		commentForm.elements["content"].focus();
+	});
+}
+
+// Let the collection form move snippets up and down the list. The snippets
+// are saved in the order of their hidden inputs, which move along with them.
+var collectionSnippets = document.querySelector("ol.collection-snippets");
+if (collectionSnippets) {
+	collectionSnippets.addEventListener("click", function (event) {
+		var item = event.target.closest("li");
+		if (!item) {
+			return;
+		}
+
+		if (event.target.classList.contains("move-up") && item.previousElementSibling) {
+			item.previousElementSibling.before(item);
+		} else if (event.target.classList.contains("move-down") && item.nextElementSibling) {
+			item.nextElementSibling.after(item);
+		}
+	});
+}